@@ -33,6 +33,7 @@ func ToPBIMUser(dbUser *models.IMUser) *im.IMUser {
 		Device:        dbUser.Device,
 		IsLogout:      dbUser.IsLogout,
 		Salt:          dbUser.Salt,
+		FriendsOnly:   dbUser.FriendsOnly,
 	}
 }
 
@@ -65,6 +66,7 @@ func ToDBIMUser(pbUser *im.IMUser) *models.IMUser {
 		Device:        pbUser.GetDevice(),
 		IsLogout:      pbUser.GetIsLogout(),
 		Salt:          pbUser.GetSalt(),
+		FriendsOnly:   pbUser.GetFriendsOnly(),
 	}
 }
 
@@ -148,3 +150,194 @@ func ProtosToFriendViews(fs *im.Friends) []models.FriendView {
 	}
 	return fvs
 }
+
+// FriendRequestView 转 FriendRequest
+func FriendRequestViewToProto(v models.FriendRequestView) *im.FriendRequest {
+	return &im.FriendRequest{
+		FromId:    v.FromID,
+		FromName:  v.FromName,
+		ToId:      v.ToID,
+		ToName:    v.ToName,
+		Note:      v.Note,
+		Status:    v.Status,
+		UpdatedAt: timestamppb.New(v.UpdatedAt),
+	}
+}
+
+// FriendRequests 转 []FriendRequestView
+func ProtosToFriendRequestViews(rs *im.FriendRequests) []models.FriendRequestView {
+	views := make([]models.FriendRequestView, 0, len(rs.Requests))
+	for _, r := range rs.Requests {
+		views = append(views, models.FriendRequestView{
+			FromID:    r.FromId,
+			FromName:  r.FromName,
+			ToID:      r.ToId,
+			ToName:    r.ToName,
+			Note:      r.Note,
+			Status:    r.Status,
+			UpdatedAt: protoToTime(r.UpdatedAt),
+		})
+	}
+	return views
+}
+
+// ToPBGroup 将群组模型转换为 protobuf 消息
+func ToPBGroup(g *models.Group, memberIDs []uint64) *im.Group {
+	if g == nil {
+		return nil
+	}
+	return &im.Group{
+		Id:        uint64(g.ID),
+		Name:      g.Name,
+		OwnerId:   g.OwnerID,
+		MemberIds: memberIDs,
+		CreatedAt: timeToProto(g.CreatedAt),
+	}
+}
+
+// Group 转 GroupView (单个)
+func ProtoToGroupView(g *im.Group) models.GroupView {
+	return models.GroupView{
+		ID:        g.Id,
+		Name:      g.Name,
+		OwnerID:   g.OwnerId,
+		MemberIDs: g.MemberIds,
+		CreatedAt: protoToTime(g.CreatedAt),
+	}
+}
+
+// Groups 转 []GroupView (切片)
+func ProtosToGroupViews(gs *im.Groups) []models.GroupView {
+	gvs := []models.GroupView{}
+	for _, g := range gs.Grouplist {
+		gvs = append(gvs, ProtoToGroupView(g))
+	}
+	return gvs
+}
+
+// ProtoToMessage 将 protobuf 消息转换为消息模型
+func ProtoToMessage(m *im.Message) models.Message {
+	return models.Message{
+		ID:          m.GetId(),
+		FromID:      m.GetFromId(),
+		ToID:        m.GetToId(),
+		Type:        m.GetType(),
+		ContentType: m.GetContentType(),
+		Content:     m.GetContent(),
+		CreatedAt:   protoToTime(m.GetCreatedAt()),
+		UpdatedAt:   protoToTime(m.GetUpdatedAt()),
+		ClientMsgID: m.GetClientMsgId(),
+		Seq:         m.GetSeq(),
+		RecalledAt:  convertProtoToTime(m.GetRecalledAt()),
+		EditedAt:    convertProtoToTime(m.GetEditedAt()),
+
+		AttachmentID: m.GetAttachmentId(),
+		Attachment:   ToDBAttachment(m.GetAttachment()),
+	}
+}
+
+// MessageToProto 将消息模型转换为 protobuf 消息
+func MessageToProto(m *models.Message) *im.Message {
+	return &im.Message{
+		Id:          m.ID,
+		FromId:      m.FromID,
+		ToId:        m.ToID,
+		Type:        m.Type,
+		ContentType: m.ContentType,
+		Content:     m.Content,
+		CreatedAt:   timeToProto(m.CreatedAt),
+		UpdatedAt:   timeToProto(m.UpdatedAt),
+		ClientMsgId: m.ClientMsgID,
+		Seq:         m.Seq,
+		RecalledAt:  convertTimeToProto(m.RecalledAt),
+		EditedAt:    convertTimeToProto(m.EditedAt),
+
+		AttachmentId: m.AttachmentID,
+		Attachment:   ToPBAttachment(m.Attachment),
+	}
+}
+
+// ProtoToSearchHit 将 protobuf 检索结果转换为检索结果模型
+func ProtoToSearchHit(h *im.SearchHit) models.SearchHit {
+	hit := models.SearchHit{
+		Message:    ProtoToMessage(h.GetMessage()),
+		Snippet:    h.GetSnippet(),
+		Highlights: make([][2]int32, len(h.GetHighlights())),
+	}
+	for i, r := range h.GetHighlights() {
+		hit.Highlights[i] = [2]int32{r.GetStart(), r.GetEnd()}
+	}
+	return hit
+}
+
+// ProtoToConversationView 将 protobuf 会话转换为会话视图
+func ProtoToConversationView(c *im.Conversation) models.ConversationView {
+	return models.ConversationView{
+		Conversation: models.Conversation{
+			Type:          c.GetType(),
+			TargetID:      c.GetTargetId(),
+			LastMessageID: c.GetLastMessageId(),
+			LastSenderID:  c.GetLastSenderId(),
+			LastPreview:   c.GetLastPreview(),
+			LastActiveAt:  protoToTime(c.GetLastActiveAt()),
+			UnreadCount:   c.GetUnreadCount(),
+			Pinned:        c.GetPinned(),
+			Hidden:        c.GetHidden(),
+			Archived:      c.GetArchived(),
+		},
+		Name: c.GetName(),
+	}
+}
+
+// ProtosToConversationViews 批量转换 protobuf 会话
+func ProtosToConversationViews(cs []*im.Conversation) []models.ConversationView {
+	views := make([]models.ConversationView, len(cs))
+	for i, c := range cs {
+		views[i] = ProtoToConversationView(c)
+	}
+	return views
+}
+
+// ToPBAttachment 将附件模型转换为 protobuf 附件
+func ToPBAttachment(a *models.Attachment) *im.Attachment {
+	if a == nil {
+		return nil
+	}
+	return &im.Attachment{
+		Id:         a.ID,
+		OwnerId:    a.OwnerID,
+		FileName:   a.FileName,
+		MimeType:   a.MimeType,
+		Size:       a.Size,
+		Sha256:     a.SHA256,
+		StorageKey: a.StorageKey,
+		CreatedAt:  timeToProto(a.CreatedAt),
+
+		Width:         a.Width,
+		Height:        a.Height,
+		DurationMs:    a.DurationMs,
+		HasThumbnails: a.HasThumbnails,
+	}
+}
+
+// ToDBAttachment 将 protobuf 附件转换为附件模型
+func ToDBAttachment(a *im.Attachment) *models.Attachment {
+	if a == nil {
+		return nil
+	}
+	return &models.Attachment{
+		ID:         a.GetId(),
+		OwnerID:    a.GetOwnerId(),
+		FileName:   a.GetFileName(),
+		MimeType:   a.GetMimeType(),
+		Size:       a.GetSize(),
+		SHA256:     a.GetSha256(),
+		StorageKey: a.GetStorageKey(),
+		CreatedAt:  protoToTime(a.GetCreatedAt()),
+
+		Width:         a.GetWidth(),
+		Height:        a.GetHeight(),
+		DurationMs:    a.GetDurationMs(),
+		HasThumbnails: a.GetHasThumbnails(),
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 	grpc_server "github.com/hoyang/imserver/src/dbproxy/rpcserver"
 	"github.com/hoyang/imserver/src/models"
+	"github.com/hoyang/imserver/src/mysql/migrations"
+	"github.com/hoyang/imserver/src/mysql/migrator"
 	"github.com/hoyang/imserver/src/utils"
 	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
@@ -64,7 +66,14 @@ func main() {
 
 	log.Println("Mysql 连接成功")
 
-	db.AutoMigrate(&models.IMUser{}, &models.Contact{}, &models.Message{}, &models.UnreadMessage{})
+	db.AutoMigrate(&models.IMUser{}, &models.Contact{}, &models.Message{}, &models.UnreadMessage{},
+		&models.Group{}, &models.GroupMember{}, &models.ConversationSeq{}, &models.Block{},
+		&models.Conversation{}, &models.MessageEdit{}, &models.Attachment{})
+
+	// AutoMigrate 无法表达的结构（生成列、全文索引等）由迁移文件维护
+	if err := migrator.NewMigrator(db).MigrateFS(migrations.FS); err != nil {
+		log.Fatalf("执行数据库迁移失败: %v", err)
+	}
 
 	grpc_server.StartRpcServer(db, redis)
 }
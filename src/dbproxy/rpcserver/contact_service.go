@@ -0,0 +1,157 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/hoyang/imserver/src/conveter"
+	"github.com/hoyang/imserver/src/models"
+	im "github.com/hoyang/imserver/src/proto"
+	"github.com/hoyang/imserver/src/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RemoveFriend 解除好友关系，双向删除
+func (s *server) RemoveFriend(ctx context.Context, contact *im.Contact) (*im.DeleteResponse, error) {
+	if contact.UserID == 0 || contact.FriendID == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+
+	result := s.db.Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)",
+		contact.UserID, contact.FriendID, contact.FriendID, contact.UserID).
+		Delete(&models.Contact{})
+	if result.Error != nil {
+		log.Printf("删除好友失败: %v\n", result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, status.Errorf(codes.NotFound, "好友关系不存在")
+	}
+
+	s.redis.Del(ctx, utils.FriendsCacheKey(contact.UserID), utils.FriendsCacheKey(contact.FriendID))
+	return &im.DeleteResponse{Success: true}, nil
+}
+
+// BlockUser 拉黑用户，同时拒绝对方待处理的好友请求
+func (s *server) BlockUser(ctx context.Context, req *im.BlockRequest) (*im.AddResponse, error) {
+	if req.UserId == 0 || req.TargetId == 0 || req.UserId == req.TargetId {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Block{UserID: req.UserId, BlockedID: req.TargetId}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Contact{}).
+			Where("user_id = ? AND friend_id = ? AND status = ?", req.TargetId, req.UserId, models.ContactPending).
+			Update("status", models.ContactRejected).Error
+	})
+	if err != nil {
+		log.Printf("拉黑用户失败: %v\n", err)
+		return nil, err
+	}
+	return &im.AddResponse{Success: true}, nil
+}
+
+// UnblockUser 解除拉黑
+func (s *server) UnblockUser(ctx context.Context, req *im.BlockRequest) (*im.DeleteResponse, error) {
+	if req.UserId == 0 || req.TargetId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+
+	result := s.db.Where("user_id = ? AND blocked_id = ?", req.UserId, req.TargetId).Delete(&models.Block{})
+	if result.Error != nil {
+		log.Printf("解除拉黑失败: %v\n", result.Error)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, status.Errorf(codes.NotFound, "未拉黑该用户")
+	}
+	return &im.DeleteResponse{Success: true}, nil
+}
+
+// GetBlockedUsers 获取用户的黑名单
+func (s *server) GetBlockedUsers(ctx context.Context, req *im.UserRequest) (*im.Friends, error) {
+	if req.Id == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "用户ID不能为空")
+	}
+
+	var blocked []models.FriendView
+	err := s.db.Table("user_blocks b").
+		Select("u.id, u.name as username").
+		Joins("JOIN user_basic u ON b.blocked_id = u.id").
+		Where("b.user_id = ?", req.Id).
+		Order("b.created_at DESC").
+		Find(&blocked).Error
+	if err != nil {
+		return nil, err
+	}
+	return conveter.FriendViewsToProtos(blocked), nil
+}
+
+// SetPrivacy 更新隐私设置
+func (s *server) SetPrivacy(ctx context.Context, req *im.PrivacyRequest) (*im.AddResponse, error) {
+	if req.UserId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "用户ID不能为空")
+	}
+
+	var dbUser models.IMUser
+	if err := s.db.Select("id", "name").First(&dbUser, req.UserId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "用户ID %d 不存在", req.UserId)
+		}
+		return nil, err
+	}
+	if err := s.db.Model(&dbUser).UpdateColumn("friends_only", req.FriendsOnly).Error; err != nil {
+		log.Printf("更新隐私设置失败: %v\n", err)
+		return nil, err
+	}
+	s.redis.Del(ctx, utils.UserIDCacheKey(uint64(dbUser.ID)), utils.UserCacheKey(dbUser.Name))
+	return &im.AddResponse{Success: true}, nil
+}
+
+// CheckSendPermission 校验 user_id 能否向 target_id 发送私聊消息：
+// 被对方拉黑时不允许；对方只接收好友消息时，双方须为好友
+func (s *server) CheckSendPermission(ctx context.Context, req *im.BlockRequest) (*im.SendPermission, error) {
+	if req.UserId == 0 || req.TargetId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+
+	var blocked int64
+	err := s.db.Model(&models.Block{}).
+		Where("user_id = ? AND blocked_id = ?", req.TargetId, req.UserId).
+		Count(&blocked).Error
+	if err != nil {
+		return nil, err
+	}
+	if blocked > 0 {
+		return &im.SendPermission{Reason: "对方拒绝接收你的消息"}, nil
+	}
+
+	var target models.IMUser
+	if err := s.db.Select("id", "friends_only").First(&target, req.TargetId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "用户ID %d 不存在", req.TargetId)
+		}
+		return nil, err
+	}
+	if target.FriendsOnly {
+		var friends int64
+		err := s.db.Model(&models.Contact{}).
+			Where("user_id = ? AND friend_id = ? AND status = ?", req.TargetId, req.UserId, models.ContactAccepted).
+			Count(&friends).Error
+		if err != nil {
+			return nil, err
+		}
+		if friends == 0 {
+			return &im.SendPermission{Reason: "对方只接收好友的消息"}, nil
+		}
+	}
+	return &im.SendPermission{Allowed: true}, nil
+}
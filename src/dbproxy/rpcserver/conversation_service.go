@@ -0,0 +1,220 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hoyang/imserver/src/models"
+	pb "github.com/hoyang/imserver/src/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// updateConversations 在存储消息的事务内更新发送者和接收者的会话索引
+// 同一会话的消息由序列号行锁串行化，最后一条消息不会被并发写入覆盖
+func updateConversations(tx *gorm.DB, msg *models.Message) error {
+	var receivers []models.Conversation
+	sender := models.Conversation{UserID: msg.FromID, Type: msg.Type, TargetID: msg.ToID}
+	switch msg.Type {
+	case models.MessageTypePrivate:
+		receivers = append(receivers, models.Conversation{UserID: msg.ToID, Type: msg.Type, TargetID: msg.FromID})
+	case models.MessageTypeGroup:
+		var memberIDs []uint64
+		err := tx.Model(&models.GroupMember{}).
+			Where("group_id = ? AND user_id <> ?", msg.ToID, msg.FromID).
+			Pluck("user_id", &memberIDs).Error
+		if err != nil {
+			return err
+		}
+		for _, id := range memberIDs {
+			receivers = append(receivers, models.Conversation{UserID: id, Type: msg.Type, TargetID: msg.ToID})
+		}
+	default:
+		return nil
+	}
+
+	if err := upsertConversations(tx, []models.Conversation{sender}, msg, 0); err != nil {
+		return err
+	}
+	if len(receivers) == 0 {
+		return nil
+	}
+	return upsertConversations(tx, receivers, msg, 1)
+}
+
+// upsertConversations 写入最后一条消息，未读数增加 unread，隐藏的会话重新显示
+func upsertConversations(tx *gorm.DB, convs []models.Conversation, msg *models.Message, unread uint32) error {
+	preview := models.MessagePreview(msg.ContentType, msg.Content)
+	now := time.Now()
+	for i := range convs {
+		convs[i].LastMessageID = msg.ID
+		convs[i].LastSenderID = msg.FromID
+		convs[i].LastPreview = preview
+		convs[i].LastActiveAt = msg.CreatedAt
+		convs[i].UnreadCount = unread
+		convs[i].UpdatedAt = now
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "type"}, {Name: "target_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"last_message_id": msg.ID,
+			"last_sender_id":  msg.FromID,
+			"last_preview":    preview,
+			"last_active_at":  msg.CreatedAt,
+			"unread_count":    gorm.Expr("unread_count + ?", unread),
+			"hidden":          false,
+			"updated_at":      now,
+		}),
+	}).Create(&convs).Error
+}
+
+// refreshPrivateUnread 按剩余的未读记录重新计算私聊会话的未读数
+func refreshPrivateUnread(tx *gorm.DB, userID uint64, peerIDs []uint64) error {
+	for _, peerID := range peerIDs {
+		err := tx.Model(&models.Conversation{}).
+			Where("user_id = ? AND type = ? AND target_id = ?", userID, models.MessageTypePrivate, peerID).
+			Update("unread_count", tx.Table("unread_messages").
+				Select("COUNT(*)").
+				Joins("JOIN messages ON messages.id = unread_messages.message_id").
+				Where("unread_messages.user_id = ? AND messages.from_id = ? AND messages.type = ?", userID, peerID, models.MessageTypePrivate)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshGroupUnread 群消息没有未读记录，已读到 readUpTo 后按之后收到的群消息数重新计算未读数
+// 只会减少未读数，乱序上报的较早已读不会把未读数加回去
+func refreshGroupUnread(tx *gorm.DB, userID, groupID, readUpTo uint64) error {
+	return tx.Model(&models.Conversation{}).
+		Where("user_id = ? AND type = ? AND target_id = ?", userID, models.MessageTypeGroup, groupID).
+		Update("unread_count", gorm.Expr("LEAST(unread_count, (?))", tx.Model(&models.Message{}).
+			Select("COUNT(*)").
+			Where("type = ? AND to_id = ? AND id > ? AND from_id <> ?", models.MessageTypeGroup, groupID, readUpTo, userID))).Error
+}
+
+// updateConversationPreview 最后一条消息被撤回或编辑后更新会话列表中的预览
+func updateConversationPreview(tx *gorm.DB, messageID uint64, preview string) error {
+	return tx.Model(&models.Conversation{}).
+		Where("last_message_id = ?", messageID).
+		Update("last_preview", preview).Error
+}
+
+// conversationViews 查询会话并补充对方用户名或群名称
+func (s *MessageServiceImpl) conversationViews(scope func(*gorm.DB) *gorm.DB) ([]models.ConversationView, error) {
+	var views []models.ConversationView
+	query := s.db.Table("conversations").
+		Select("conversations.*, COALESCE(user_basic.name, im_groups.name, '') AS name").
+		Joins("LEFT JOIN user_basic ON conversations.type = ? AND user_basic.id = conversations.target_id", models.MessageTypePrivate).
+		Joins("LEFT JOIN im_groups ON conversations.type = ? AND im_groups.id = conversations.target_id", models.MessageTypeGroup)
+	err := scope(query).Scan(&views).Error
+	return views, err
+}
+
+// ListConversations 获取用户的会话列表（不含隐藏的会话）
+func (s *MessageServiceImpl) ListConversations(ctx context.Context, req *pb.ListConversationsRequest) (*pb.ListConversationsResponse, error) {
+	if req.UserId == 0 || req.Offset < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	limit := int(req.Limit)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	views, err := s.conversationViews(func(db *gorm.DB) *gorm.DB {
+		return db.Where("conversations.user_id = ? AND conversations.hidden = ? AND conversations.archived = ?", req.UserId, false, req.Archived).
+			Order("conversations.pinned DESC, conversations.last_active_at DESC").
+			Offset(int(req.Offset)).
+			Limit(limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListConversationsResponse{Conversations: make([]*pb.Conversation, len(views))}
+	for i := range views {
+		resp.Conversations[i] = convertToProtoConversation(&views[i])
+	}
+	return resp, nil
+}
+
+// UpdateConversation 更新会话的置顶/隐藏/归档标记，或清零未读数
+func (s *MessageServiceImpl) UpdateConversation(ctx context.Context, req *pb.UpdateConversationRequest) (*pb.UpdateConversationResponse, error) {
+	if req.UserId == 0 || req.TargetId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	updates := map[string]any{}
+	if req.Pinned != nil {
+		updates["pinned"] = *req.Pinned
+	}
+	if req.Hidden != nil {
+		updates["hidden"] = *req.Hidden
+	}
+	if req.Archived != nil {
+		updates["archived"] = *req.Archived
+	}
+	if req.ClearUnread {
+		updates["unread_count"] = 0
+	}
+	if len(updates) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "没有需要更新的内容")
+	}
+
+	where := func(db *gorm.DB) *gorm.DB {
+		return db.Where("conversations.user_id = ? AND conversations.type = ? AND conversations.target_id = ?", req.UserId, req.Type, req.TargetId)
+	}
+	var conv models.Conversation
+	if err := where(s.db).First(&conv).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "会话不存在")
+		}
+		return nil, err
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&conv).Updates(updates).Error; err != nil {
+			return err
+		}
+		if !req.ClearUnread || req.Type != models.MessageTypePrivate {
+			return nil
+		}
+		// 同时删除该会话的未读记录，否则下次按未读记录重算时未读数会恢复
+		return tx.Where("user_id = ? AND message_id IN (?)", req.UserId, tx.Model(&models.Message{}).
+			Select("id").
+			Where("type = ? AND from_id = ? AND to_id = ?", models.MessageTypePrivate, req.TargetId, req.UserId)).
+			Delete(&models.UnreadMessage{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	views, err := s.conversationViews(where)
+	if err != nil {
+		return nil, err
+	}
+	if len(views) == 0 {
+		return nil, status.Errorf(codes.NotFound, "会话不存在")
+	}
+	return &pb.UpdateConversationResponse{Conversation: convertToProtoConversation(&views[0])}, nil
+}
+
+// convertToProtoConversation 将会话视图转换为 proto 会话
+func convertToProtoConversation(v *models.ConversationView) *pb.Conversation {
+	return &pb.Conversation{
+		Type:          v.Type,
+		TargetId:      v.TargetID,
+		Name:          v.Name,
+		LastMessageId: v.LastMessageID,
+		LastSenderId:  v.LastSenderID,
+		LastPreview:   v.LastPreview,
+		LastActiveAt:  timestamppb.New(v.LastActiveAt),
+		UnreadCount:   v.UnreadCount,
+		Pinned:        v.Pinned,
+		Hidden:        v.Hidden,
+		Archived:      v.Archived,
+	}
+}
@@ -0,0 +1,262 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/hoyang/imserver/src/conveter"
+	"github.com/hoyang/imserver/src/models"
+	pb "github.com/hoyang/imserver/src/proto"
+	"github.com/hoyang/imserver/src/utils"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GroupServiceImpl 群组服务实现
+type GroupServiceImpl struct {
+	pb.UnimplementedGroupServiceServer
+	db    *gorm.DB
+	redis *redis.Client
+}
+
+// NewGroupService 创建群组服务实例
+func NewGroupService(db *gorm.DB, redis *redis.Client) *GroupServiceImpl {
+	return &GroupServiceImpl{db: db, redis: redis}
+}
+
+// CreateGroup 创建群组，群主自动成为成员；初始成员的筛选同 AddMembers
+func (s *GroupServiceImpl) CreateGroup(ctx context.Context, req *pb.CreateGroupRequest) (*pb.Group, error) {
+	if req.Name == "" || req.OwnerId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "群名称和群主不能为空")
+	}
+
+	invited, err := s.invitableUsers(req.OwnerId, req.MemberIds)
+	if err != nil {
+		log.Printf("查询被邀请用户失败: %v\n", err)
+		return nil, status.Errorf(codes.Internal, "服务器内部错误")
+	}
+
+	group := &models.Group{Name: req.Name, OwnerID: req.OwnerId}
+	memberIDs := []uint64{req.OwnerId}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		members := []models.GroupMember{{GroupID: uint64(group.ID), UserID: req.OwnerId, Role: models.GroupRoleOwner}}
+		for _, id := range invited {
+			if id == req.OwnerId {
+				continue
+			}
+			members = append(members, models.GroupMember{GroupID: uint64(group.ID), UserID: id, Role: models.GroupRoleMember})
+			memberIDs = append(memberIDs, id)
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+	})
+	if err != nil {
+		log.Printf("创建群组失败: %v\n", err)
+		return nil, status.Errorf(codes.Internal, "创建群组失败")
+	}
+	log.Printf("创建群组成功, groupId: %d\n", group.ID)
+
+	return conveter.ToPBGroup(group, memberIDs), nil
+}
+
+// DissolveGroup 解散群组
+func (s *GroupServiceImpl) DissolveGroup(ctx context.Context, req *pb.GroupRequest) (*pb.GroupResponse, error) {
+	group, err := s.getGroup(req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if group.OwnerID != req.OperatorId {
+		return nil, status.Errorf(codes.PermissionDenied, "只有群主可以解散群组")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", req.GroupId).Delete(&models.GroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(group).Error
+	})
+	if err != nil {
+		log.Printf("解散群组失败: %v\n", err)
+		return nil, status.Errorf(codes.Internal, "解散群组失败")
+	}
+
+	s.redis.Del(ctx, utils.GroupMembersCacheKey(req.GroupId))
+	return &pb.GroupResponse{Success: true}, nil
+}
+
+// AddMembers 添加群成员，操作者必须是群成员；不存在的用户和拉黑了操作者的用户会被跳过
+func (s *GroupServiceImpl) AddMembers(ctx context.Context, req *pb.GroupMembersRequest) (*pb.GroupResponse, error) {
+	if _, err := s.getGroup(req.GroupId); err != nil {
+		return nil, err
+	}
+	isMember, err := s.isMember(req.GroupId, req.OperatorId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "服务器内部错误")
+	}
+	if !isMember {
+		return nil, status.Errorf(codes.PermissionDenied, "非群成员不能邀请他人")
+	}
+
+	userIDs, err := s.invitableUsers(req.OperatorId, req.UserIds)
+	if err != nil {
+		log.Printf("查询被邀请用户失败: %v\n", err)
+		return nil, status.Errorf(codes.Internal, "服务器内部错误")
+	}
+	if len(userIDs) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "没有可添加的成员")
+	}
+	members := make([]models.GroupMember, len(userIDs))
+	for i, id := range userIDs {
+		members[i] = models.GroupMember{GroupID: req.GroupId, UserID: id, Role: models.GroupRoleMember}
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error; err != nil {
+		log.Printf("添加群成员失败: %v\n", err)
+		return nil, status.Errorf(codes.Internal, "添加群成员失败")
+	}
+
+	s.redis.Del(ctx, utils.GroupMembersCacheKey(req.GroupId))
+	return &pb.GroupResponse{Success: true}, nil
+}
+
+// RemoveMembers 移除群成员，群主可移除他人，普通成员只能退出
+func (s *GroupServiceImpl) RemoveMembers(ctx context.Context, req *pb.GroupMembersRequest) (*pb.GroupResponse, error) {
+	group, err := s.getGroup(req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.UserIds) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "成员列表不能为空")
+	}
+	for _, id := range req.UserIds {
+		if id == group.OwnerID {
+			return nil, status.Errorf(codes.FailedPrecondition, "群主不能退出群组，请解散群组")
+		}
+		if group.OwnerID != req.OperatorId && id != req.OperatorId {
+			return nil, status.Errorf(codes.PermissionDenied, "只有群主可以移除其他成员")
+		}
+	}
+
+	err = s.db.Where("group_id = ? AND user_id IN ?", req.GroupId, req.UserIds).
+		Delete(&models.GroupMember{}).Error
+	if err != nil {
+		log.Printf("移除群成员失败: %v\n", err)
+		return nil, status.Errorf(codes.Internal, "移除群成员失败")
+	}
+
+	s.redis.Del(ctx, utils.GroupMembersCacheKey(req.GroupId))
+	return &pb.GroupResponse{Success: true}, nil
+}
+
+// GetUserGroups 获取用户加入的群组
+func (s *GroupServiceImpl) GetUserGroups(ctx context.Context, req *pb.ListGroupsRequest) (*pb.Groups, error) {
+	var groups []models.Group
+	err := s.db.Model(&models.Group{}).
+		Joins("JOIN group_members gm ON gm.group_id = im_groups.id").
+		Where("gm.user_id = ?", req.UserId).
+		Order("im_groups.id DESC").
+		Find(&groups).Error
+	if err != nil {
+		log.Printf("查询用户群组失败: %v\n", err)
+		return nil, status.Errorf(codes.Internal, "服务器内部错误")
+	}
+
+	result := &pb.Groups{}
+	for i := range groups {
+		memberIDs, err := s.getMemberIDs(ctx, uint64(groups[i].ID))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "服务器内部错误")
+		}
+		result.Grouplist = append(result.Grouplist, conveter.ToPBGroup(&groups[i], memberIDs))
+	}
+	return result, nil
+}
+
+// GetGroupMembers 获取群成员ID列表
+func (s *GroupServiceImpl) GetGroupMembers(ctx context.Context, req *pb.GroupRequest) (*pb.GroupMembers, error) {
+	if req.GroupId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "群组ID不能为空")
+	}
+	memberIDs, err := s.getMemberIDs(ctx, req.GroupId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "服务器内部错误")
+	}
+	return &pb.GroupMembers{MemberIds: memberIDs}, nil
+}
+
+// getMemberIDs 获取群成员ID，优先读取缓存
+func (s *GroupServiceImpl) getMemberIDs(ctx context.Context, groupID uint64) ([]uint64, error) {
+	cacheKey := utils.GroupMembersCacheKey(groupID)
+	cached, err := s.redis.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var members pb.GroupMembers
+		if err := proto.Unmarshal([]byte(cached), &members); err != nil {
+			log.Printf("反序列化群成员缓存失败: %v", err)
+		} else {
+			return members.MemberIds, nil
+		}
+	} else if err != redis.Nil {
+		log.Printf("查询 Redis 缓存失败: %v", err)
+	}
+
+	var memberIDs []uint64
+	err = s.db.Model(&models.GroupMember{}).
+		Where("group_id = ?", groupID).
+		Order("created_at ASC").
+		Pluck("user_id", &memberIDs).Error
+	if err != nil {
+		log.Printf("查询群成员失败: %v\n", err)
+		return nil, err
+	}
+
+	data, err := proto.Marshal(&pb.GroupMembers{MemberIds: memberIDs})
+	if err != nil {
+		log.Printf("序列化群成员失败: %v", err)
+	} else {
+		s.redis.Set(ctx, cacheKey, data, 10*time.Minute)
+	}
+	return memberIDs, nil
+}
+
+func (s *GroupServiceImpl) getGroup(groupID uint64) (*models.Group, error) {
+	if groupID == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "群组ID不能为空")
+	}
+	var group models.Group
+	if err := s.db.First(&group, groupID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "群组 %d 不存在", groupID)
+		}
+		log.Printf("查询群组失败: %v\n", err)
+		return nil, status.Errorf(codes.Internal, "服务器内部错误")
+	}
+	return &group, nil
+}
+
+// invitableUsers 从 userIDs 中筛选出存在且没有拉黑 operatorID 的用户
+func (s *GroupServiceImpl) invitableUsers(operatorID uint64, userIDs []uint64) ([]uint64, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	var ids []uint64
+	err := s.db.Model(&models.IMUser{}).
+		Where("id IN ?", userIDs).
+		Where("id NOT IN (?)", s.db.Model(&models.Block{}).Select("user_id").Where("blocked_id = ?", operatorID)).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (s *GroupServiceImpl) isMember(groupID, userID uint64) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.GroupMember{}).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,152 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hoyang/imserver/src/conveter"
+	"github.com/hoyang/imserver/src/models"
+	pb "github.com/hoyang/imserver/src/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// MediaServiceImpl 媒体服务实现，只管理附件元数据
+type MediaServiceImpl struct {
+	pb.UnimplementedMediaServiceServer
+	db *gorm.DB
+}
+
+// NewMediaService 创建媒体服务实例
+func NewMediaService(db *gorm.DB) *MediaServiceImpl {
+	return &MediaServiceImpl{db: db}
+}
+
+// CreateAttachment 登记已写入存储的附件
+func (s *MediaServiceImpl) CreateAttachment(ctx context.Context, req *pb.Attachment) (*pb.Attachment, error) {
+	if req.OwnerId == 0 || req.StorageKey == "" || req.Size <= 0 || len(req.Sha256) != 64 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	// 同一用户重复上传相同内容时返回已有记录
+	existing, err := findOwnAttachment(s.db, req.OwnerId, req.Sha256, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return conveter.ToPBAttachment(existing), nil
+	}
+
+	attachment := conveter.ToDBAttachment(req)
+	attachment.ID = 0
+	attachment.CreatedAt = time.Now()
+	if err := s.db.Create(attachment).Error; err != nil {
+		return nil, err
+	}
+	return conveter.ToPBAttachment(attachment), nil
+}
+
+// GetAttachment 获取附件，校验请求者的访问权限
+func (s *MediaServiceImpl) GetAttachment(ctx context.Context, req *pb.AttachmentRequest) (*pb.Attachment, error) {
+	if req.Id == 0 || req.UserId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	attachment, err := findAttachment(s.db, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := canAccessAttachment(s.db, attachment, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "无权访问该附件")
+	}
+	return conveter.ToPBAttachment(attachment), nil
+}
+
+// FindAttachmentByHash 按内容哈希查找用户自己上传过的附件，不存在时返回 NotFound
+// 只匹配自己的附件：仅凭哈希不能证明持有文件，不能借此获取他人的附件
+func (s *MediaServiceImpl) FindAttachmentByHash(ctx context.Context, req *pb.AttachmentHashRequest) (*pb.Attachment, error) {
+	if req.UserId == 0 || len(req.Sha256) != 64 || req.Size <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	attachment, err := findOwnAttachment(s.db, req.UserId, req.Sha256, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, status.Errorf(codes.NotFound, "附件不存在")
+	}
+	return conveter.ToPBAttachment(attachment), nil
+}
+
+// findOwnAttachment 查找用户上传过的相同内容的附件，不存在时返回 nil
+func findOwnAttachment(db *gorm.DB, ownerID uint64, sum string, size int64) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := db.Where("owner_id = ? AND sha256 = ? AND size = ?", ownerID, sum, size).
+		Order("id ASC").First(&attachment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// findAttachment 按ID查找附件，不存在时返回 NotFound
+func findAttachment(db *gorm.DB, id uint64) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := db.First(&attachment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "附件不存在")
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// canAccessAttachment 上传者，以及引用该附件且未撤回的消息的参与者（私聊双方、群成员）可以访问附件
+func canAccessAttachment(db *gorm.DB, attachment *models.Attachment, userID uint64) (bool, error) {
+	if attachment.OwnerID == userID {
+		return true, nil
+	}
+	var count int64
+	err := db.Model(&models.Message{}).
+		Where("attachment_id = ? AND recalled_at IS NULL", attachment.ID).
+		Where(db.Where("type = ? AND (from_id = ? OR to_id = ?)", models.MessageTypePrivate, userID, userID).
+			Or("type = ? AND to_id IN (?)", models.MessageTypeGroup,
+				db.Model(&models.GroupMember{}).Select("group_id").Where("user_id = ?", userID))).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// loadAttachments 批量填充消息引用的附件元数据，已撤回的消息不返回附件
+func loadAttachments(db *gorm.DB, messages []*models.Message) error {
+	ids := make([]uint64, 0, len(messages))
+	for _, msg := range messages {
+		if msg.AttachmentID != 0 && msg.RecalledAt == nil {
+			ids = append(ids, msg.AttachmentID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var attachments []*models.Attachment
+	if err := db.Where("id IN ?", ids).Find(&attachments).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint64]*models.Attachment, len(attachments))
+	for _, a := range attachments {
+		byID[a.ID] = a
+	}
+	for _, msg := range messages {
+		if msg.RecalledAt == nil {
+			msg.Attachment = byID[msg.AttachmentID]
+		}
+	}
+	return nil
+}
@@ -2,43 +2,136 @@ package grpc_server
 
 import (
 	"context"
+	"errors"
+	"log"
+	"os"
+	"slices"
 	"time"
+	"unicode/utf8"
 
+	"github.com/hoyang/imserver/src/conveter"
 	"github.com/hoyang/imserver/src/models"
 	pb "github.com/hoyang/imserver/src/proto"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// 默认的消息撤回时限
+const defaultRecallWindow = 2 * time.Minute
+
+// 消息内容的最大字节数，与 content 列的 BLOB 类型一致
+const maxContentSize = 65535
+
 // MessageServiceImpl 消息服务实现
 type MessageServiceImpl struct {
 	pb.UnimplementedMessageServiceServer
-	db *gorm.DB
+	db           *gorm.DB
+	recallWindow time.Duration // 发送后多久内允许撤回
 }
 
 // NewMessageService 创建消息服务实例
 func NewMessageService(db *gorm.DB) *MessageServiceImpl {
-	return &MessageServiceImpl{db: db}
+	return &MessageServiceImpl{db: db, recallWindow: loadRecallWindow()}
+}
+
+// loadRecallWindow 读取撤回时限：环境变量 MESSAGE_RECALL_WINDOW 优先，其次配置文件 message.recall_window
+func loadRecallWindow() time.Duration {
+	value := os.Getenv("MESSAGE_RECALL_WINDOW")
+	if value == "" {
+		value = viper.GetString("message.recall_window")
+	}
+	if value == "" {
+		return defaultRecallWindow
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 {
+		log.Printf("撤回时限配置无效: %q，使用默认值 %v", value, defaultRecallWindow)
+		return defaultRecallWindow
+	}
+	return window
 }
 
-// StoreMessage 存储消息
+// StoreMessage 存储消息，携带 client_msg_id 的重复请求返回已存在的消息
 func (s *MessageServiceImpl) StoreMessage(ctx context.Context, req *pb.StoreMessageRequest) (*pb.StoreMessageResponse, error) {
 	msg := req.Message
+	if msg == nil || msg.FromId == 0 || msg.ToId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	// 文本消息须为合法的UTF-8，全文检索列按UTF-8转换内容
+	if msg.ContentType == pb.ContentType_TEXT && !utf8.Valid(msg.Content) {
+		return nil, status.Errorf(codes.InvalidArgument, "文本消息必须是UTF-8编码")
+	}
+
+	// 客户端重发：直接返回已存储的消息
+	if existing, err := s.findByClientMsgID(msg.FromId, msg.ClientMsgId); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return &pb.StoreMessageResponse{
+			MessageId: existing.ID,
+			Duplicate: true,
+			CreatedAt: timestamppb.New(existing.CreatedAt),
+			Seq:       existing.Seq,
+		}, nil
+	}
+
+	// 引用附件时，发送者必须有权访问该附件，防止借消息获取他人的附件
+	var attachment *models.Attachment
+	if msg.AttachmentId != 0 {
+		var err error
+		attachment, err = findAttachment(s.db, msg.AttachmentId)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil, status.Errorf(codes.InvalidArgument, "附件不存在")
+			}
+			return nil, err
+		}
+		ok, err := canAccessAttachment(s.db, attachment, msg.FromId)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "无权引用该附件")
+		}
+	}
+
+	// 1. 存储消息
+	modelMsg := &models.Message{
+		FromID:      msg.FromId,
+		ToID:        msg.ToId,
+		Type:        msg.Type,
+		ContentType: msg.ContentType,
+		Content:     msg.Content,
+		CreatedAt:   msg.CreatedAt.AsTime(),
+		UpdatedAt:   msg.UpdatedAt.AsTime(),
+		ClientMsgID: msg.ClientMsgId,
+		ConvKey:     models.ConversationKey(msg.Type, msg.FromId, msg.ToId),
+
+		AttachmentID: msg.AttachmentId,
+	}
+	if msg.CreatedAt == nil {
+		modelMsg.CreatedAt = time.Now()
+		modelMsg.UpdatedAt = modelMsg.CreatedAt
+	}
 
 	// 开启事务
 	err := s.db.Transaction(func(tx *gorm.DB) error {
-		// 1. 存储消息
-		modelMsg := &models.Message{
-			FromID:      msg.FromId,
-			ToID:        msg.ToId,
-			Type:        msg.Type,
-			ContentType: msg.ContentType,
-			Content:     msg.Content,
-			CreatedAt:   msg.CreatedAt.AsTime(),
-			UpdatedAt:   msg.UpdatedAt.AsTime(),
-		}
-
-		if err := tx.Create(modelMsg).Error; err != nil {
+		// 分配会话序列号，行锁持有到事务提交，保证同一会话内序列号连续递增
+		seq, err := nextSeq(tx, modelMsg.ConvKey)
+		if err != nil {
+			return err
+		}
+		modelMsg.Seq = seq
+
+		create := tx
+		if modelMsg.ClientMsgID == "" {
+			// 未携带客户端ID时写入NULL，避免唯一索引冲突
+			create = tx.Omit("ClientMsgID")
+		}
+		if err := create.Create(modelMsg).Error; err != nil {
 			return err
 		}
 
@@ -54,27 +147,89 @@ func (s *MessageServiceImpl) StoreMessage(ctx context.Context, req *pb.StoreMess
 			}
 		}
 
-		msg.Id = modelMsg.ID
-		return nil
+		// 3. 更新双方（群聊为全体成员）的会话列表
+		return updateConversations(tx, modelMsg)
 	})
 
 	if err != nil {
+		// 并发重发时唯一索引冲突，返回先写入的消息
+		if existing, _ := s.findByClientMsgID(msg.FromId, msg.ClientMsgId); existing != nil {
+			return &pb.StoreMessageResponse{
+				MessageId: existing.ID,
+				Duplicate: true,
+				CreatedAt: timestamppb.New(existing.CreatedAt),
+				Seq:       existing.Seq,
+			}, nil
+		}
 		return nil, err
 	}
 
 	return &pb.StoreMessageResponse{
-		MessageId: msg.Id,
+		MessageId:  modelMsg.ID,
+		CreatedAt:  timestamppb.New(modelMsg.CreatedAt),
+		Seq:        modelMsg.Seq,
+		Attachment: conveter.ToPBAttachment(attachment),
+	}, nil
+}
+
+// nextSeq 在事务内为会话分配下一个序列号
+func nextSeq(tx *gorm.DB, convKey string) (uint64, error) {
+	err := tx.Exec("INSERT INTO conversation_seqs (conv_key, seq) VALUES (?, 1) ON DUPLICATE KEY UPDATE seq = seq + 1", convKey).Error
+	if err != nil {
+		return 0, err
+	}
+	var cs models.ConversationSeq
+	if err := tx.Where("conv_key = ?", convKey).First(&cs).Error; err != nil {
+		return 0, err
+	}
+	return cs.Seq, nil
+}
+
+// FindMessageByClientMsgID 按 client_msg_id 查找已存储的消息，返回与重复存储相同的响应
+func (s *MessageServiceImpl) FindMessageByClientMsgID(ctx context.Context, req *pb.FindMessageByClientMsgIDRequest) (*pb.StoreMessageResponse, error) {
+	if req.FromId == 0 || req.ClientMsgId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	existing, err := s.findByClientMsgID(req.FromId, req.ClientMsgId)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, status.Errorf(codes.NotFound, "消息不存在")
+	}
+	return &pb.StoreMessageResponse{
+		MessageId: existing.ID,
+		Duplicate: true,
+		CreatedAt: timestamppb.New(existing.CreatedAt),
+		Seq:       existing.Seq,
 	}, nil
 }
 
-// GetUnreadMessages 获取未读消息（仅用于单聊）
+// findByClientMsgID 按发送者和客户端消息ID查找已存储的消息
+func (s *MessageServiceImpl) findByClientMsgID(fromID uint64, clientMsgID string) (*models.Message, error) {
+	if clientMsgID == "" {
+		return nil, nil
+	}
+	var msg models.Message
+	err := s.db.Where("from_id = ? AND client_msg_id = ?", fromID, clientMsgID).First(&msg).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// GetUnreadMessages 获取待投递的未读消息（仅用于单聊）
 func (s *MessageServiceImpl) GetUnreadMessages(ctx context.Context, req *pb.GetUnreadMessagesRequest) (*pb.GetUnreadMessagesResponse, error) {
 	var messages []*models.Message
 
 	// 只查询私聊消息
 	query := s.db.Model(&models.Message{}).
 		Joins("JOIN unread_messages ON messages.id = unread_messages.message_id").
-		Where("unread_messages.user_id = ? AND messages.type = ?", req.UserId, models.MessageTypePrivate)
+		Where("unread_messages.user_id = ? AND messages.type = ?", req.UserId, models.MessageTypePrivate).
+		Where("unread_messages.delivered_at IS NULL")
 
 	if req.LastMessageId > 0 {
 		query = query.Where("messages.id > ?", req.LastMessageId)
@@ -88,6 +243,10 @@ func (s *MessageServiceImpl) GetUnreadMessages(ctx context.Context, req *pb.GetU
 		return nil, err
 	}
 
+	if err := loadAttachments(s.db, messages); err != nil {
+		return nil, err
+	}
+
 	// 转换为 proto 消息
 	protoMessages := make([]*pb.Message, len(messages))
 	for i, msg := range messages {
@@ -99,22 +258,37 @@ func (s *MessageServiceImpl) GetUnreadMessages(ctx context.Context, req *pb.GetU
 	}, nil
 }
 
-// GetGroupMessages 获取群聊消息（分页）
+// GetGroupMessages 获取群聊消息（按消息ID游标向前分页），只有群成员可以查询
 func (s *MessageServiceImpl) GetGroupMessages(ctx context.Context, req *pb.GetGroupMessagesRequest) (*pb.GetGroupMessagesResponse, error) {
-	var messages []*models.Message
+	if req.UserId == 0 || req.GroupId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	if err := s.checkGroupMember(req.GroupId, req.UserId); err != nil {
+		return nil, err
+	}
 
+	limit := int(req.Limit)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
 	query := s.db.Model(&models.Message{}).
 		Where("type = ? AND to_id = ?", models.MessageTypeGroup, req.GroupId)
-
 	if req.LastMessageId > 0 {
 		query = query.Where("id < ?", req.LastMessageId)
 	}
 
-	err := query.Order("id DESC").
-		Limit(int(req.Limit)).
-		Find(&messages).Error
+	// 多取一条用于判断是否还有更多
+	var messages []*models.Message
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	slices.Reverse(messages)
 
-	if err != nil {
+	if err := loadAttachments(s.db, messages); err != nil {
 		return nil, err
 	}
 
@@ -126,19 +300,337 @@ func (s *MessageServiceImpl) GetGroupMessages(ctx context.Context, req *pb.GetGr
 
 	return &pb.GetGroupMessagesResponse{
 		Messages: protoMessages,
+		HasMore:  hasMore,
 	}, nil
 }
 
+// checkGroupMember 请求者不是群成员时返回 PermissionDenied
+func (s *MessageServiceImpl) checkGroupMember(groupID, userID uint64) error {
+	var count int64
+	err := s.db.Model(&models.GroupMember{}).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return status.Errorf(codes.PermissionDenied, "非群成员")
+	}
+	return nil
+}
+
+// MarkRead 标记消息已读并删除未读记录，返回按发送者聚合的回执；群消息只更新会话未读数
+func (s *MessageServiceImpl) MarkRead(ctx context.Context, req *pb.MarkReadRequest) (*pb.MarkReadResponse, error) {
+	if req.UserId == 0 || (len(req.MessageIds) == 0 && req.PeerId == 0) {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+
+	query := s.db.Table("unread_messages").
+		Select("unread_messages.message_id, messages.from_id").
+		Joins("JOIN messages ON messages.id = unread_messages.message_id").
+		Where("unread_messages.user_id = ?", req.UserId)
+	if len(req.MessageIds) > 0 {
+		query = query.Where("unread_messages.message_id IN ?", req.MessageIds)
+	} else {
+		query = query.Where("messages.from_id = ? AND messages.type = ?", req.PeerId, models.MessageTypePrivate)
+		if req.UpToMessageId > 0 {
+			query = query.Where("unread_messages.message_id <= ?", req.UpToMessageId)
+		}
+	}
+
+	var rows []struct {
+		MessageID uint64
+		FromID    uint64
+	}
+	if err := query.Order("unread_messages.message_id ASC").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// 群消息不记录未读记录，按各群已读到的最大消息ID重算会话未读数
+	var groupReads []struct {
+		GroupID   uint64
+		MessageID uint64
+	}
+	if len(req.MessageIds) > 0 {
+		err := s.db.Model(&models.Message{}).
+			Select("to_id AS group_id, MAX(id) AS message_id").
+			Where("id IN ? AND type = ?", req.MessageIds, models.MessageTypeGroup).
+			Group("to_id").
+			Scan(&groupReads).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	readAt := time.Now()
+	resp := &pb.MarkReadResponse{ReadAt: timestamppb.New(readAt)}
+	if len(rows) == 0 && len(groupReads) == 0 {
+		return resp, nil
+	}
+
+	messageIDs := make([]uint64, 0, len(rows))
+	receipts := make(map[uint64]*pb.ReadReceipt)
+	for _, row := range rows {
+		messageIDs = append(messageIDs, row.MessageID)
+		receipt, ok := receipts[row.FromID]
+		if !ok {
+			receipt = &pb.ReadReceipt{FromId: row.FromID}
+			receipts[row.FromID] = receipt
+			resp.Receipts = append(resp.Receipts, receipt)
+		}
+		receipt.MessageIds = append(receipt.MessageIds, row.MessageID)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, read := range groupReads {
+			if err := refreshGroupUnread(tx, req.UserId, read.GroupID, read.MessageID); err != nil {
+				return err
+			}
+		}
+		if len(messageIDs) == 0 {
+			return nil
+		}
+		err := tx.Where("user_id = ? AND message_id IN ?", req.UserId, messageIDs).
+			Delete(&models.UnreadMessage{}).Error
+		if err != nil {
+			return err
+		}
+		peerIDs := make([]uint64, len(resp.Receipts))
+		for i, receipt := range resp.Receipts {
+			peerIDs[i] = receipt.FromId
+		}
+		return refreshPrivateUnread(tx, req.UserId, peerIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// AckMessages 记录客户端已确认送达的消息，已送达的消息不再作为离线消息补发
+func (s *MessageServiceImpl) AckMessages(ctx context.Context, req *pb.AckMessagesRequest) (*pb.AckMessagesResponse, error) {
+	if req.UserId == 0 || len(req.MessageIds) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+
+	result := s.db.Model(&models.UnreadMessage{}).
+		Where("user_id = ? AND message_id IN ? AND delivered_at IS NULL", req.UserId, req.MessageIds).
+		Update("delivered_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &pb.AckMessagesResponse{Acked: result.RowsAffected}, nil
+}
+
+// GetMessagesBySeq 按会话序列号区间获取消息
+func (s *MessageServiceImpl) GetMessagesBySeq(ctx context.Context, req *pb.GetMessagesBySeqRequest) (*pb.GetMessagesBySeqResponse, error) {
+	if req.UserId == 0 || req.TargetId == 0 || req.FromSeq == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	switch req.Type {
+	case models.MessageTypePrivate:
+	case models.MessageTypeGroup:
+		if err := s.checkGroupMember(req.TargetId, req.UserId); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "消息类型无效")
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 || limit > 200 {
+		limit = 200
+	}
+	query := s.db.Model(&models.Message{}).
+		Where("conv_key = ? AND seq >= ?", models.ConversationKey(req.Type, req.UserId, req.TargetId), req.FromSeq)
+	if req.ToSeq > 0 {
+		query = query.Where("seq <= ?", req.ToSeq)
+	}
+
+	var messages []*models.Message
+	if err := query.Order("seq ASC").Limit(limit).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	if err := loadAttachments(s.db, messages); err != nil {
+		return nil, err
+	}
+	protoMessages := make([]*pb.Message, len(messages))
+	for i, msg := range messages {
+		protoMessages[i] = convertToProtoMessage(msg)
+	}
+	return &pb.GetMessagesBySeqResponse{Messages: protoMessages}, nil
+}
+
+// GetConversationMessages 获取两个用户之间双向的私聊历史
+// 只按请求者所在的会话查询，请求者必然是会话一方
+func (s *MessageServiceImpl) GetConversationMessages(ctx context.Context, req *pb.GetConversationMessagesRequest) (*pb.GetConversationMessagesResponse, error) {
+	if req.UserId == 0 || req.PeerId == 0 || req.UserId == req.PeerId {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	if req.BeforeId > 0 && req.AfterId > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "before_id 和 after_id 不能同时指定")
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	// 早期消息没有会话标识，按收发双方匹配；已注销用户的消息只能通过会话标识匹配
+	query := s.db.Model(&models.Message{}).
+		Where("type = ?", models.MessageTypePrivate).
+		Where(s.db.Where("conv_key = ?", models.ConversationKey(models.MessageTypePrivate, req.UserId, req.PeerId)).
+			Or("from_id = ? AND to_id = ?", req.UserId, req.PeerId).
+			Or("from_id = ? AND to_id = ?", req.PeerId, req.UserId))
+
+	order := "id DESC"
+	switch {
+	case req.BeforeId > 0:
+		query = query.Where("id < ?", req.BeforeId)
+	case req.AfterId > 0:
+		query = query.Where("id > ?", req.AfterId)
+		order = "id ASC"
+	}
+
+	// 多取一条用于判断是否还有更多
+	var messages []*models.Message
+	if err := query.Order(order).Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	if order == "id DESC" {
+		slices.Reverse(messages)
+	}
+
+	if err := loadAttachments(s.db, messages); err != nil {
+		return nil, err
+	}
+	protoMessages := make([]*pb.Message, len(messages))
+	for i, msg := range messages {
+		protoMessages[i] = convertToProtoMessage(msg)
+	}
+	return &pb.GetConversationMessagesResponse{Messages: protoMessages, HasMore: hasMore}, nil
+}
+
+// RecallMessage 撤回消息：清空内容并记录撤回时间，保留的记录作为墓碑返回给后续拉取历史的客户端
+// 编辑历史中保存的旧内容一并删除；私聊消息的未读记录一并删除，撤回的消息不再作为离线消息补发
+func (s *MessageServiceImpl) RecallMessage(ctx context.Context, req *pb.RecallMessageRequest) (*pb.MessageChangeResponse, error) {
+	if req.UserId == 0 || req.MessageId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+
+	var msg models.Message
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockOwnMessage(tx, req.UserId, req.MessageId, &msg); err != nil {
+			return err
+		}
+		now := time.Now()
+		if now.Sub(msg.CreatedAt) > s.recallWindow {
+			return status.Errorf(codes.FailedPrecondition, "已超过撤回时限")
+		}
+
+		msg.Content = []byte{}
+		msg.RecalledAt = &now
+		msg.UpdatedAt = now
+		err := tx.Model(&msg).Updates(map[string]any{
+			"content":     msg.Content,
+			"recalled_at": now,
+			"updated_at":  now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("message_id = ?", msg.ID).Delete(&models.MessageEdit{}).Error; err != nil {
+			return err
+		}
+
+		if msg.Type == models.MessageTypePrivate {
+			if err := tx.Where("message_id = ?", msg.ID).Delete(&models.UnreadMessage{}).Error; err != nil {
+				return err
+			}
+			if err := refreshPrivateUnread(tx, msg.ToID, []uint64{msg.FromID}); err != nil {
+				return err
+			}
+		}
+		return updateConversationPreview(tx, msg.ID, models.RecalledPreview)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.MessageChangeResponse{Message: convertToProtoMessage(&msg)}, nil
+}
+
+// EditMessage 编辑消息，编辑前的内容保存到编辑历史
+// 只能编辑不带附件的文本消息
+func (s *MessageServiceImpl) EditMessage(ctx context.Context, req *pb.EditMessageRequest) (*pb.MessageChangeResponse, error) {
+	if req.UserId == 0 || req.MessageId == 0 || len(req.Content) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	if len(req.Content) > maxContentSize {
+		return nil, status.Errorf(codes.InvalidArgument, "消息内容过长")
+	}
+
+	var msg models.Message
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockOwnMessage(tx, req.UserId, req.MessageId, &msg); err != nil {
+			return err
+		}
+		if msg.ContentType != pb.ContentType_TEXT || msg.AttachmentID != 0 {
+			return status.Errorf(codes.FailedPrecondition, "只能编辑文本消息")
+		}
+		if !utf8.Valid(req.Content) {
+			return status.Errorf(codes.InvalidArgument, "文本消息必须是UTF-8编码")
+		}
+
+		now := time.Now()
+		edit := &models.MessageEdit{MessageID: msg.ID, Content: msg.Content, EditedAt: now}
+		if err := tx.Create(edit).Error; err != nil {
+			return err
+		}
+
+		msg.Content = req.Content
+		msg.EditedAt = &now
+		msg.UpdatedAt = now
+		err := tx.Model(&msg).Updates(map[string]any{
+			"content":    msg.Content,
+			"edited_at":  now,
+			"updated_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return updateConversationPreview(tx, msg.ID, models.MessagePreview(msg.ContentType, msg.Content))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.MessageChangeResponse{Message: convertToProtoMessage(&msg)}, nil
+}
+
+// lockOwnMessage 加锁读取消息，并检查操作者是发送者且消息未被撤回
+func (s *MessageServiceImpl) lockOwnMessage(tx *gorm.DB, userID, messageID uint64, msg *models.Message) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(msg, messageID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status.Errorf(codes.NotFound, "消息不存在")
+		}
+		return err
+	}
+	if msg.FromID != userID {
+		return status.Errorf(codes.PermissionDenied, "只能修改自己发送的消息")
+	}
+	if msg.RecalledAt != nil {
+		return status.Errorf(codes.FailedPrecondition, "消息已撤回")
+	}
+	return nil
+}
+
 // convertToProtoMessage 将模型消息转换为 proto 消息
 func convertToProtoMessage(msg *models.Message) *pb.Message {
-	return &pb.Message{
-		Id:          msg.ID,
-		FromId:      msg.FromID,
-		ToId:        msg.ToID,
-		Type:        msg.Type,
-		ContentType: msg.ContentType,
-		Content:     msg.Content,
-		CreatedAt:   timestamppb.New(msg.CreatedAt),
-		UpdatedAt:   timestamppb.New(msg.UpdatedAt),
-	}
+	return conveter.MessageToProto(msg)
 }
@@ -3,6 +3,7 @@ package grpc_server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net"
 	"os"
@@ -20,6 +21,7 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type server struct {
@@ -35,7 +37,9 @@ func StartRpcServer(db *gorm.DB, redis *redis.Client) {
 	}
 	rpcServer := grpc.NewServer()
 	im.RegisterUserServiceServer(rpcServer, &server{db: db, redis: redis})
-	im.RegisterMessageServiceServer(rpcServer, &MessageServiceImpl{db: db})
+	im.RegisterMessageServiceServer(rpcServer, NewMessageService(db))
+	im.RegisterGroupServiceServer(rpcServer, NewGroupService(db, redis))
+	im.RegisterMediaServiceServer(rpcServer, NewMediaService(db))
 	log.Printf("server listening at %v\n", listen.Addr())
 	go func() {
 		if err := rpcServer.Serve(listen); err != nil {
@@ -227,13 +231,13 @@ func (s *server) GetFriends(ctx context.Context, req *im.UserRequest) (*im.Frien
 		Select(`
 	        u.id,
 	        u.name as username,
-	        u.is_logout,
 	        uf.status,
 	        uf.created_at
 	    `).
 		Joins("JOIN user_basic u ON uf.friend_id = u.id").
 		Where("uf.user_id  = (?)", req.Id).
-		//Where("uf.status = ?", "accepted"). // 只返回已接受的好友关系
+		// 只返回已接受的好友关系
+		Where("uf.status = ? AND uf.deleted_at IS NULL", models.ContactAccepted).
 		Order("uf.created_at DESC"). // 按创建时间排序
 		Find(&friends).Error
 	//var user models.IMUser
@@ -258,42 +262,300 @@ func (s *server) GetFriends(ctx context.Context, req *im.UserRequest) (*im.Frien
 	return friendsView, nil
 }
 
+// AddFriend 发送好友请求；对方已向自己发出请求时直接成为好友
 func (s *server) AddFriend(ctx context.Context, contact *im.Contact) (*im.AddResponse, error) {
+	if contact.UserID == 0 || contact.FriendID == 0 || contact.UserID == contact.FriendID {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+
 	resp := im.AddResponse{Success: true}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 被对方拉黑时不能发送请求
+		var blocked int64
+		err := tx.Model(&models.Block{}).
+			Where("user_id = ? AND blocked_id = ?", contact.FriendID, contact.UserID).
+			Count(&blocked).Error
+		if err != nil {
+			return err
+		}
+		if blocked > 0 {
+			return status.Errorf(codes.PermissionDenied, "无法添加该用户")
+		}
+
+		var existing models.Contact
+		err = tx.Where("user_id = ? AND friend_id = ?", contact.UserID, contact.FriendID).First(&existing).Error
+		if err == nil && existing.Status == models.ContactAccepted {
+			return status.Errorf(codes.AlreadyExists, "已经是好友")
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// 对方已发出待处理的请求，视为同意
+		var reverse models.Contact
+		err = tx.Where("user_id = ? AND friend_id = ? AND status = ?", contact.FriendID, contact.UserID, models.ContactPending).
+			First(&reverse).Error
+		if err == nil {
+			resp.Accepted = true
+			return acceptFriend(tx, contact.FriendID, contact.UserID)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return upsertContact(tx, &models.Contact{
+			UserID:   contact.UserID,
+			FriendID: contact.FriendID,
+			Status:   models.ContactPending,
+			Note:     contact.Note,
+		})
+	})
+	if err != nil {
+		log.Printf("发送好友请求失败: %v\n", err)
+		return nil, err
+	}
 
-	tx := s.db.Begin()
-	if tx.Error != nil {
-		resp.Success = false
-		return &resp, tx.Error
+	if resp.Accepted {
+		// 成为好友后，删除双方的好友列表缓存
+		s.redis.Del(ctx, utils.FriendsCacheKey(contact.UserID), utils.FriendsCacheKey(contact.FriendID))
 	}
+	return &resp, nil
+}
 
-	userShip1 := models.Contact{UserID: uint64(contact.UserID), FriendID: uint64(contact.FriendID), Status: "accepted"}
-	result := s.db.Create(&userShip1)
-	// 检查插入是否成功
+// GetFriendRequests 查询待处理的好友请求：默认为收到的请求，outgoing 时为自己发出的请求（含被拒绝的）
+func (s *server) GetFriendRequests(ctx context.Context, req *im.FriendRequestsQuery) (*im.FriendRequests, error) {
+	if req.UserId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "用户ID不能为空")
+	}
 
-	if result.Error != nil {
-		tx.Rollback()
-		resp.Success = false
-		return &resp, result.Error
+	query := s.db.Table("user_friends uf").
+		Select(`
+	        uf.user_id as from_id,
+	        fu.name as from_name,
+	        uf.friend_id as to_id,
+	        tu.name as to_name,
+	        uf.note,
+	        uf.status,
+	        uf.updated_at
+	    `).
+		Joins("JOIN user_basic fu ON uf.user_id = fu.id").
+		Joins("JOIN user_basic tu ON uf.friend_id = tu.id").
+		Where("uf.deleted_at IS NULL")
+	if req.Outgoing {
+		query = query.Where("uf.user_id = ? AND uf.status IN ?", req.UserId, []string{models.ContactPending, models.ContactRejected})
+	} else {
+		query = query.Where("uf.friend_id = ? AND uf.status = ?", req.UserId, models.ContactPending)
 	}
 
-	userShip2 := models.Contact{UserID: uint64(contact.FriendID), FriendID: uint64(contact.UserID), Status: "accepted"}
-	result = s.db.Create(&userShip2)
-	// 检查插入是否成功
+	var views []models.FriendRequestView
+	if err := query.Order("uf.updated_at DESC").Find(&views).Error; err != nil {
+		return nil, err
+	}
 
-	if result.Error != nil {
-		tx.Rollback()
-		resp.Success = false
-		return &resp, result.Error
+	resp := &im.FriendRequests{}
+	for _, v := range views {
+		resp.Requests = append(resp.Requests, conveter.FriendRequestViewToProto(v))
 	}
-	if tx.Commit().Error != nil {
-		resp.Success = false
-		return &resp, result.Error
+	return resp, nil
+}
+
+// RespondFriendRequest 接受或拒绝 from_id 发给 user_id 的好友请求
+func (s *server) RespondFriendRequest(ctx context.Context, req *im.FriendReply) (*im.AddResponse, error) {
+	if req.UserId == 0 || req.FromId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
 	}
 
-	// 添加成功后，删除双方的好友列表缓存
-	s.redis.Del(ctx, utils.FriendsCacheKey(contact.UserID))
-	s.redis.Del(ctx, utils.FriendsCacheKey(contact.FriendID))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var pending models.Contact
+		err := tx.Where("user_id = ? AND friend_id = ? AND status = ?", req.FromId, req.UserId, models.ContactPending).
+			First(&pending).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status.Errorf(codes.NotFound, "好友请求不存在")
+		} else if err != nil {
+			return err
+		}
 
-	return &resp, nil
+		if req.Accept {
+			return acceptFriend(tx, req.FromId, req.UserId)
+		}
+		return tx.Model(&pending).Update("status", models.ContactRejected).Error
+	})
+	if err != nil {
+		log.Printf("处理好友请求失败: %v\n", err)
+		return nil, err
+	}
+
+	if req.Accept {
+		s.redis.Del(ctx, utils.FriendsCacheKey(req.UserId), utils.FriendsCacheKey(req.FromId))
+	}
+	return &im.AddResponse{Success: true, Accepted: req.Accept}, nil
+}
+
+// acceptFriend 接受 fromID 向 toID 发出的请求，写入双向的好友关系
+func acceptFriend(tx *gorm.DB, fromID, toID uint64) error {
+	err := tx.Model(&models.Contact{}).
+		Where("user_id = ? AND friend_id = ?", fromID, toID).
+		Update("status", models.ContactAccepted).Error
+	if err != nil {
+		return err
+	}
+	return upsertContact(tx, &models.Contact{UserID: toID, FriendID: fromID, Status: models.ContactAccepted})
+}
+
+// upsertContact 写入好友关系，已存在（包括已删除的）记录时覆盖状态
+func upsertContact(tx *gorm.DB, contact *models.Contact) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "friend_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "note", "updated_at", "deleted_at"}),
+	}).Create(contact).Error
+}
+
+// UpdatePresence 在线状态变更时记录最后心跳时间
+func (s *server) UpdatePresence(ctx context.Context, req *im.PresenceRequest) (*im.PresenceResponse, error) {
+	if req.UserId == 0 || req.HeartbeatTime == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	var dbUser models.IMUser
+	if err := s.db.Select("id", "name").First(&dbUser, req.UserId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "用户ID %d 不存在", req.UserId)
+		}
+		return nil, err
+	}
+	err := s.db.Model(&dbUser).UpdateColumn("heartbeat_time", req.HeartbeatTime.AsTime()).Error
+	if err != nil {
+		log.Printf("更新心跳时间失败: %v\n", err)
+		return nil, err
+	}
+	// 删除用户缓存，避免旧数据覆盖心跳时间
+	s.redis.Del(ctx, utils.UserIDCacheKey(uint64(dbUser.ID)), utils.UserCacheKey(dbUser.Name))
+	return &im.PresenceResponse{Success: true}, nil
+}
+
+// DeleteUser 注销账号：软删除用户并释放用户名等唯一字段，解除双向好友关系，
+// 清理黑名单和未读记录，退出所在的群（转让或解散自己的群），保留的消息匿名化为已注销用户
+func (s *server) DeleteUser(ctx context.Context, req *im.UserRequest) (*im.DeleteResponse, error) {
+	if req.Id == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "用户ID不能为空")
+	}
+
+	var dbUser models.IMUser
+	if err := s.db.First(&dbUser, req.Id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "用户ID %d 不存在", req.Id)
+		}
+		return nil, err
+	}
+
+	name := dbUser.Name
+	var friendIDs, groupIDs []uint64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Contact{}).
+			Where("user_id = ?", req.Id).
+			Pluck("friend_id", &friendIDs).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().
+			Where("user_id = ? OR friend_id = ?", req.Id, req.Id).
+			Delete(&models.Contact{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("user_id = ? OR blocked_id = ?", req.Id, req.Id).Delete(&models.Block{}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", req.Id).Delete(&models.UnreadMessage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", req.Id).Delete(&models.Conversation{}).Error; err != nil {
+			return err
+		}
+		if err := leaveAllGroups(tx, req.Id, &groupIDs); err != nil {
+			return err
+		}
+
+		// 匿名化保留的消息，清除客户端消息ID避免唯一索引冲突
+		err = tx.Model(&models.Message{}).
+			Where("from_id = ?", req.Id).
+			Updates(map[string]any{"from_id": models.DeletedUserID, "client_msg_id": nil}).Error
+		if err != nil {
+			return err
+		}
+
+		// 释放用户名、手机号、邮箱，清除个人信息后软删除
+		err = tx.Model(&dbUser).Updates(map[string]any{
+			"name":      fmt.Sprintf("deleted_%d", dbUser.ID),
+			"phone":     nil,
+			"email":     nil,
+			"password":  "",
+			"salt":      "",
+			"client_ip": "",
+			"is_logout": true,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&dbUser).Error
+	})
+	if err != nil {
+		log.Printf("注销用户失败: %v\n", err)
+		return nil, err
+	}
+	log.Printf("注销用户成功, userId: %d\n", req.Id)
+
+	keys := []string{
+		utils.UserIDCacheKey(req.Id),
+		utils.UserCacheKey(name),
+		utils.FriendsCacheKey(req.Id),
+	}
+	for _, id := range friendIDs {
+		keys = append(keys, utils.FriendsCacheKey(id))
+	}
+	for _, id := range groupIDs {
+		keys = append(keys, utils.GroupMembersCacheKey(id))
+	}
+	s.redis.Del(ctx, keys...)
+
+	return &im.DeleteResponse{Success: true}, nil
+}
+
+// leaveAllGroups 注销用户退出所在的全部群：自己是群主的群转让给最早入群的成员，没有其他成员时解散
+// groupIDs 返回受影响的群，用于清除成员缓存
+func leaveAllGroups(tx *gorm.DB, userID uint64, groupIDs *[]uint64) error {
+	err := tx.Model(&models.GroupMember{}).Where("user_id = ?", userID).Pluck("group_id", groupIDs).Error
+	if err != nil {
+		return err
+	}
+
+	var owned []models.Group
+	if err := tx.Where("owner_id = ?", userID).Find(&owned).Error; err != nil {
+		return err
+	}
+	for _, group := range owned {
+		*groupIDs = append(*groupIDs, uint64(group.ID))
+
+		var heir models.GroupMember
+		err := tx.Where("group_id = ? AND user_id <> ?", group.ID, userID).
+			Order("created_at, user_id").
+			First(&heir).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Delete(&group).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&group).Update("owner_id", heir.UserID).Error; err != nil {
+			return err
+		}
+		err = tx.Model(&models.GroupMember{}).
+			Where("group_id = ? AND user_id = ?", heir.GroupID, heir.UserID).
+			Update("role", models.GroupRoleOwner).Error
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.GroupMember{}).Error
 }
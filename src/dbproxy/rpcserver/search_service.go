@@ -0,0 +1,183 @@
+package grpc_server
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hoyang/imserver/src/models"
+	pb "github.com/hoyang/imserver/src/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 检索参数
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 100
+	maxSearchTerms     = 8
+	ngramTokenSize     = 2  // 与 MySQL 的 ngram_token_size 一致，更短的词无法走全文索引
+	snippetContext     = 20 // 片段中关键词之前保留的字符数
+	snippetLength      = 80 // 片段的最大字符数
+)
+
+// SearchMessages 在用户参与的会话中检索文本消息
+// 全文条件使用 messages.search_text 上的 ngram 索引（见迁移 000002_add_message_fulltext）
+func (s *MessageServiceImpl) SearchMessages(ctx context.Context, req *pb.SearchMessagesRequest) (*pb.SearchMessagesResponse, error) {
+	terms := searchTerms(req.Query)
+	if req.UserId == 0 || len(terms) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "参数错误")
+	}
+	if req.PeerId > 0 && req.GroupId > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "peer_id 和 group_id 不能同时指定")
+	}
+	limit := int(req.Limit)
+	if limit <= 0 || limit > searchMaxLimit {
+		limit = searchDefaultLimit
+	}
+
+	query := s.db.Model(&models.Message{}).Where("recalled_at IS NULL")
+
+	// 长词走全文索引（短语匹配），短于分词长度的词逐条匹配
+	var phrases []string
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) >= ngramTokenSize {
+			phrases = append(phrases, `+"`+term+`"`)
+		} else {
+			query = query.Where("search_text LIKE ?", "%"+escapeLike(term)+"%")
+		}
+	}
+	if len(phrases) > 0 {
+		query = query.Where("MATCH(search_text) AGAINST(? IN BOOLEAN MODE)", strings.Join(phrases, " "))
+	} else {
+		query = query.Where("search_text IS NOT NULL")
+	}
+
+	// 只检索用户参与的会话：私聊的一方，或所在群的消息
+	memberGroups := s.db.Model(&models.GroupMember{}).Select("group_id").Where("user_id = ?", req.UserId)
+	switch {
+	case req.PeerId > 0:
+		query = query.Where("type = ?", models.MessageTypePrivate).
+			Where(s.db.Where("conv_key = ?", models.ConversationKey(models.MessageTypePrivate, req.UserId, req.PeerId)).
+				Or("from_id = ? AND to_id = ?", req.UserId, req.PeerId).
+				Or("from_id = ? AND to_id = ?", req.PeerId, req.UserId))
+	case req.GroupId > 0:
+		query = query.Where("type = ? AND to_id = ? AND to_id IN (?)", models.MessageTypeGroup, req.GroupId, memberGroups)
+	default:
+		query = query.Where(s.db.Where("type = ? AND (from_id = ? OR to_id = ?)", models.MessageTypePrivate, req.UserId, req.UserId).
+			Or("type = ? AND to_id IN (?)", models.MessageTypeGroup, memberGroups))
+	}
+
+	if req.SenderId > 0 {
+		query = query.Where("from_id = ?", req.SenderId)
+	}
+	if req.ContentType != nil {
+		query = query.Where("content_type = ?", req.GetContentType())
+	}
+	if req.StartTime != nil {
+		query = query.Where("created_at >= ?", req.StartTime.AsTime())
+	}
+	if req.EndTime != nil {
+		query = query.Where("created_at <= ?", req.EndTime.AsTime())
+	}
+	if req.BeforeId > 0 {
+		query = query.Where("id < ?", req.BeforeId)
+	}
+
+	// 多取一条用于判断是否还有更多
+	var messages []*models.Message
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	if err := loadAttachments(s.db, messages); err != nil {
+		return nil, err
+	}
+
+	hits := make([]*pb.SearchHit, len(messages))
+	for i, msg := range messages {
+		snippet, highlights := highlight(string(msg.Content), terms)
+		hits[i] = &pb.SearchHit{
+			Message:    convertToProtoMessage(msg),
+			Snippet:    snippet,
+			Highlights: highlights,
+		}
+	}
+	return &pb.SearchMessagesResponse{Hits: hits, HasMore: hasMore}, nil
+}
+
+// searchTerms 按空白拆分关键词并去重，去掉在布尔模式中有特殊含义的双引号
+func searchTerms(query string) []string {
+	var terms []string
+	for _, field := range strings.Fields(strings.ReplaceAll(query, `"`, " ")) {
+		if !slices.Contains(terms, field) {
+			terms = append(terms, field)
+		}
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// escapeLike 转义 LIKE 模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// highlight 截取包含第一个关键词的片段，并标出片段中所有关键词的位置（不区分大小写）
+func highlight(content string, terms []string) (string, []*pb.HighlightRange) {
+	text := []rune(content)
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// 每个关键词的全部出现位置
+	type span struct{ start, end int }
+	var spans []span
+	for _, term := range terms {
+		t := []rune(strings.ToLower(term))
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if slices.Equal(lower[i:i+len(t)], t) {
+				spans = append(spans, span{i, i + len(t)})
+			}
+		}
+	}
+	slices.SortFunc(spans, func(a, b span) int { return a.start - b.start })
+
+	start := 0
+	if len(spans) > 0 {
+		start = max(spans[0].start-snippetContext, 0)
+	}
+	end := min(start+snippetLength, len(text))
+
+	var prefix, suffix string
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(text) {
+		suffix = "…"
+	}
+	offset := utf8.RuneCountInString(prefix) - start
+
+	// 合并重叠的区间，只保留完整落在片段内的
+	var highlights []*pb.HighlightRange
+	for _, sp := range spans {
+		if sp.start < start || sp.end > end {
+			continue
+		}
+		s, e := int32(sp.start+offset), int32(sp.end+offset)
+		if n := len(highlights); n > 0 && s <= highlights[n-1].End {
+			highlights[n-1].End = max(highlights[n-1].End, e)
+			continue
+		}
+		highlights = append(highlights, &pb.HighlightRange{Start: s, End: e})
+	}
+	return prefix + string(text[start:end]) + suffix, highlights
+}
@@ -0,0 +1,137 @@
+package grpc_server
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "  hello   world ", want: []string{"hello", "world"}},
+		{query: "a b a", want: []string{"a", "b"}},
+		{query: `"exact phrase"`, want: []string{"exact", "phrase"}},
+		{query: `""`, want: nil},
+		{query: "你好 世界", want: []string{"你好", "世界"}},
+		{query: "1 2 3 4 5 6 7 8 9 10", want: []string{"1", "2", "3", "4", "5", "6", "7", "8"}},
+	}
+	for _, tt := range tests {
+		if got := searchTerms(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("searchTerms(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "100%", want: `100\%`},
+		{in: "a_b", want: `a\_b`},
+		{in: `C:\dir`, want: `C:\\dir`},
+		{in: `%_\`, want: `\%\_\\`},
+		{in: "百分之%五十", want: `百分之\%五十`},
+	}
+	for _, tt := range tests {
+		if got := escapeLike(tt.in); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	long := strings.Repeat("中", 30) + "关键词" + strings.Repeat("文", 100)
+	far := "ab" + strings.Repeat("x", 100) + "ab"
+
+	tests := []struct {
+		name       string
+		content    string
+		terms      []string
+		snippet    string
+		highlights [][2]int32
+	}{
+		{
+			name:       "中文按字符计",
+			content:    "你好世界",
+			terms:      []string{"世界"},
+			snippet:    "你好世界",
+			highlights: [][2]int32{{2, 4}},
+		},
+		{
+			name:       "表情符号",
+			content:    "👍好👍",
+			terms:      []string{"好"},
+			snippet:    "👍好👍",
+			highlights: [][2]int32{{1, 2}},
+		},
+		{
+			name:       "不区分大小写",
+			content:    "Hello WORLD",
+			terms:      []string{"world"},
+			snippet:    "Hello WORLD",
+			highlights: [][2]int32{{6, 11}},
+		},
+		{
+			name:       "多个关键词与重叠合并",
+			content:    "abcdef abc",
+			terms:      []string{"abc", "cde"},
+			snippet:    "abcdef abc",
+			highlights: [][2]int32{{0, 5}, {7, 10}},
+		},
+		{
+			name:       "截取片段并加省略号",
+			content:    long,
+			terms:      []string{"关键词"},
+			snippet:    "…" + strings.Repeat("中", 20) + "关键词" + strings.Repeat("文", 57) + "…",
+			highlights: [][2]int32{{21, 24}},
+		},
+		{
+			name:       "片段外的出现不标出",
+			content:    far,
+			terms:      []string{"ab"},
+			snippet:    "ab" + strings.Repeat("x", 78) + "…",
+			highlights: [][2]int32{{0, 2}},
+		},
+		{
+			name:    "没有命中",
+			content: "hello",
+			terms:   []string{"zz"},
+			snippet: "hello",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet, highlights := highlight(tt.content, tt.terms)
+			if snippet != tt.snippet {
+				t.Errorf("snippet = %q, want %q", snippet, tt.snippet)
+			}
+			got := make([][2]int32, len(highlights))
+			for i, h := range highlights {
+				got[i] = [2]int32{h.Start, h.End}
+			}
+			if !slices.Equal(got, tt.highlights) {
+				t.Errorf("highlights = %v, want %v", got, tt.highlights)
+			}
+			// 区间按字符截取后应与关键词一致（不区分大小写）
+			runes := []rune(snippet)
+			for _, h := range highlights {
+				if !containsTerm(string(runes[h.Start:h.End]), tt.terms) {
+					t.Errorf("highlight %q does not contain a term", string(runes[h.Start:h.End]))
+				}
+			}
+		})
+	}
+}
+
+func containsTerm(s string, terms []string) bool {
+	for _, term := range terms {
+		if strings.Contains(strings.ToLower(s), strings.ToLower(term)) {
+			return true
+		}
+	}
+	return false
+}
@@ -15,6 +15,1176 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "以 JWKS 格式导出非对称签名密钥的公钥，供其他服务验证访问令牌",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "令牌验证公钥",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/group/addMembers": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "群组模块"
+                ],
+                "summary": "添加群成员",
+                "parameters": [
+                    {
+                        "description": "群组ID与成员ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.GroupMembersReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/group/create": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "群组模块"
+                ],
+                "summary": "创建群组",
+                "parameters": [
+                    {
+                        "description": "群名称与初始成员",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CreateGroupReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupView"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/group/dissolve": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "群组模块"
+                ],
+                "summary": "解散群组",
+                "parameters": [
+                    {
+                        "description": "群组ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.GroupReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/group/list": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "群组模块"
+                ],
+                "summary": "获取我加入的群组",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.GroupView"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/group/members": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "群组模块"
+                ],
+                "summary": "获取群成员",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "群组ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/group/removeMembers": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "群组模块"
+                ],
+                "summary": "移除群成员或退出群组",
+                "parameters": [
+                    {
+                        "description": "群组ID与成员ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.GroupMembersReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/media/upload": {
+            "post": {
+                "description": "multipart 上传单个文件（字段名 file），返回附件信息；发送图片、语音等消息时在 AttachmentId 中引用附件ID\n图片返回宽高并生成缩略图，语音返回时长",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "媒体模块"
+                ],
+                "summary": "上传媒体文件",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "文件，最大20MB",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "语音时长（毫秒）",
+                        "name": "duration",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Attachment"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/media/uploads": {
+            "post": {
+                "description": "声明文件大小和SHA-256后按返回的 chunkSize 分片上传；自己上传过相同内容时直接返回 attachment（秒传），不创建会话",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "媒体模块"
+                ],
+                "summary": "创建分片上传会话",
+                "parameters": [
+                    {
+                        "description": "文件信息",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.InitUploadReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadSession"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/media/uploads/{uploadId}": {
+            "get": {
+                "description": "断线重连后据 received 续传缺失的分片",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "媒体模块"
+                ],
+                "summary": "查询分片上传进度",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上传会话ID",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadSession"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "删除上传会话和已上传的分片",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "媒体模块"
+                ],
+                "summary": "取消分片上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上传会话ID",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/media/uploads/{uploadId}/chunks/{index}": {
+            "put": {
+                "description": "请求体为分片的原始字节；除最后一片外每片必须正好 chunkSize 字节，重复上传同一分片时覆盖",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "媒体模块"
+                ],
+                "summary": "上传一个分片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上传会话ID",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片序号，从0开始",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/media/uploads/{uploadId}/complete": {
+            "post": {
+                "description": "拼接全部分片并校验大小和SHA-256，通过后登记附件；校验失败时返回409，分片保留可重传",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "媒体模块"
+                ],
+                "summary": "完成分片上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上传会话ID",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Attachment"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/media/{id}": {
+            "get": {
+                "description": "只有上传者和引用该附件的消息的参与者可以下载，支持 Range 请求",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "媒体模块"
+                ],
+                "summary": "下载媒体文件",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "附件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/media/{id}/thumb": {
+            "get": {
+                "description": "权限与下载原图相同；size 为 small（最长边160）或 medium（最长边480），统一为 JPEG",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "媒体模块"
+                ],
+                "summary": "下载图片缩略图",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "附件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "缩略图规格 small|medium，默认 small",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/media/{id}/url": {
+            "get": {
+                "description": "链接不依赖登录态，可用于跨域的 img 标签和下载工具，过期前有效；持有者失去访问权限（如消息被撤回）后链接随之失效",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "媒体模块"
+                ],
+                "summary": "获取附件的签名下载链接",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "附件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "缩略图规格 small|medium，不指定时为原文件",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SignedURL"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/message/conversations": {
+            "get": {
+                "description": "置顶的会话在前，其余按最后活跃时间倒序；不含隐藏的会话",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "消息模块"
+                ],
+                "summary": "获取会话列表",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "true 时返回归档的会话",
+                        "name": "archived",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "偏移量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "数量，默认50，最大200",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ConversationView"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/message/conversations/update": {
+            "post": {
+                "description": "置顶、隐藏、归档会话，或清零未读数；隐藏的会话收到新消息后重新显示",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "消息模块"
+                ],
+                "summary": "更新会话",
+                "parameters": [
+                    {
+                        "description": "会话与要更新的标记",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.UpdateConversationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ConversationView"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/message/edit": {
+            "post": {
+                "description": "只能编辑自己发送且未撤回的文本消息（不含附件），内容不超过65535字节；编辑前的内容保存为编辑历史，会话参与者收到 edit 事件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "消息模块"
+                ],
+                "summary": "编辑消息",
+                "parameters": [
+                    {
+                        "description": "消息ID与新内容",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.EditMessageReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/message/groupHistory": {
+            "get": {
+                "description": "只有群成员可以查询；返回的消息按ID升序，before 向前翻页，不指定时返回最新的消息",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "消息模块"
+                ],
+                "summary": "获取群聊历史",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "群组ID",
+                        "name": "groupId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回ID小于该值的消息",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "数量，默认50，最大200",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessagePage"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/message/history": {
+            "get": {
+                "description": "返回与对方之间双向的消息，按ID升序；before 向前翻页，after 向后翻页，都不指定时返回最新的消息",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "消息模块"
+                ],
+                "summary": "获取私聊历史",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "会话对方ID",
+                        "name": "peerId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回ID小于该值的消息",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回ID大于该值的消息",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "数量，默认50，最大200",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessagePage"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/message/recall": {
+            "post": {
+                "description": "只能撤回自己发送的消息，且需在撤回时限内；会话参与者收到 recall 事件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "消息模块"
+                ],
+                "summary": "撤回消息",
+                "parameters": [
+                    {
+                        "description": "消息ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.RecallMessageReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/message/search": {
+            "get": {
+                "description": "在自己参与的私聊和群聊中检索文本消息，多个关键词用空格分隔且须同时出现；按时间倒序，before 向前翻页",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "消息模块"
+                ],
+                "summary": "检索消息",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "关键词",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "只检索与该用户的私聊",
+                        "name": "peerId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "只检索该群",
+                        "name": "groupId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "只检索该用户发送的消息",
+                        "name": "senderId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "内容类型",
+                        "name": "contentType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "起始时间（RFC3339）",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间（RFC3339）",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回ID小于该值的结果",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "数量，默认20，最大100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/refresh": {
+            "post": {
+                "description": "使用刷新令牌（Cookie refresh_token 或请求体）换取新的访问令牌，刷新令牌同时轮换",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "刷新访问令牌",
+                "parameters": [
+                    {
+                        "description": "刷新令牌（未使用 Cookie 时）",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/service.RefreshReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/acceptFriend": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "接受好友请求",
+                "parameters": [
+                    {
+                        "description": "请求发起者ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.FriendReplyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/addfriend": {
+            "post": {
+                "description": "对方已向自己发出请求时直接成为好友",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "发送好友请求",
+                "parameters": [
+                    {
+                        "description": "好友用户名与附言",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.AddFriendReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/block": {
+            "post": {
+                "description": "被拉黑的用户无法再发送私聊消息和好友请求",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "拉黑用户",
+                "parameters": [
+                    {
+                        "description": "用户ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.BlockReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/blocked": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "获取黑名单",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.FriendView"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/delete": {
+            "post": {
+                "description": "需要确认密码；注销后好友关系被解除，已发送的消息保留但匿名化",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "注销账号",
+                "parameters": [
+                    {
+                        "description": "当前密码",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.DeleteUserReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/friendRequests": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "获取好友请求",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "incoming（默认，收到的请求）或 outgoing（发出的请求）",
+                        "name": "direction",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.FriendRequestView"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/privacy": {
+            "post": {
+                "description": "friendsOnly 为 true 时只接收好友的私聊消息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "更新隐私设置",
+                "parameters": [
+                    {
+                        "description": "隐私设置",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.PrivacyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/rejectFriend": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "拒绝好友请求",
+                "parameters": [
+                    {
+                        "description": "请求发起者ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.FriendReplyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/removeFriend": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "删除好友",
+                "parameters": [
+                    {
+                        "description": "好友ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.FriendIDReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/unblock": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "解除拉黑",
+                "parameters": [
+                    {
+                        "description": "用户ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.BlockReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/example/helloworld": {
             "get": {
                 "description": "do ping",
@@ -73,6 +1243,62 @@ const docTemplate = `{
                 "responses": {}
             }
         },
+        "/media/{id}": {
+            "get": {
+                "description": "无需登录，校验签名和有效期，并重新校验签发对象的访问权限；支持 Range 请求",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "媒体模块"
+                ],
+                "summary": "通过签名链接下载附件",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "附件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "签发对象的用户ID",
+                        "name": "uid",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "过期时间（Unix秒）",
+                        "name": "exp",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "缩略图规格",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "签名",
+                        "name": "sig",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
         "/register": {
             "post": {
                 "tags": [
@@ -213,6 +1439,7 @@ const docTemplate = `{
                         "bearerAuth": []
                     }
                 ],
+                "description": "只能更新自己的信息；修改密码时须提供原密码，成功后此前签发的令牌全部失效",
                 "tags": [
                     "用户模块"
                 ],
@@ -220,7 +1447,7 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Id",
+                        "description": "Id（可省略，须与当前用户一致）",
                         "name": "id",
                         "in": "formData"
                     },
@@ -232,10 +1459,16 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "密码",
+                        "description": "新密码",
                         "name": "password",
                         "in": "formData"
                     },
+                    {
+                        "type": "string",
+                        "description": "原密码（修改密码时必填）",
+                        "name": "oldPassword",
+                        "in": "formData"
+                    },
                     {
                         "type": "string",
                         "description": "手机号",
@@ -296,6 +1529,575 @@ const docTemplate = `{
                 "responses": {}
             }
         }
+    },
+    "definitions": {
+        "im.ContentType": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2
+            ],
+            "x-enum-varnames": [
+                "ContentType_TEXT",
+                "ContentType_PICUTRE",
+                "ContentType_VOICE"
+            ]
+        },
+        "im.MessageType": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2
+            ],
+            "x-enum-comments": {
+                "MessageType_GROUP": "群聊消息",
+                "MessageType_PRIVATE": "私聊消息",
+                "MessageType_UNKNOWN": "未知类型"
+            },
+            "x-enum-varnames": [
+                "MessageType_UNKNOWN",
+                "MessageType_PRIVATE",
+                "MessageType_GROUP"
+            ]
+        },
+        "models.Attachment": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "durationMs": {
+                    "description": "语音时长（毫秒）",
+                    "type": "integer"
+                },
+                "fileName": {
+                    "description": "原始文件名",
+                    "type": "string"
+                },
+                "hasThumbnails": {
+                    "description": "缩略图通过 /api/media/{id}/thumb?size= 获取",
+                    "type": "boolean"
+                },
+                "height": {
+                    "description": "图片高度（像素）",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mimeType": {
+                    "description": "服务端检测的MIME类型",
+                    "type": "string"
+                },
+                "ownerId": {
+                    "description": "上传者ID",
+                    "type": "integer"
+                },
+                "sha256": {
+                    "description": "内容哈希（十六进制）",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "字节数",
+                    "type": "integer"
+                },
+                "width": {
+                    "description": "客户端据此在下载前排版消息气泡",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ConversationView": {
+            "type": "object",
+            "properties": {
+                "archived": {
+                    "description": "归档，只在归档列表中显示",
+                    "type": "boolean"
+                },
+                "hidden": {
+                    "description": "从列表中隐藏，收到新消息后重新显示",
+                    "type": "boolean"
+                },
+                "lastActiveAt": {
+                    "type": "string"
+                },
+                "lastMessageId": {
+                    "type": "integer"
+                },
+                "lastPreview": {
+                    "description": "最后一条消息的预览",
+                    "type": "string"
+                },
+                "lastSenderId": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pinned": {
+                    "description": "置顶",
+                    "type": "boolean"
+                },
+                "targetId": {
+                    "description": "私聊为对方用户ID，群聊为群组ID",
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "会话类型：1-私聊 2-群聊",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/im.MessageType"
+                        }
+                    ]
+                },
+                "unreadCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.FriendRequestView": {
+            "type": "object",
+            "properties": {
+                "fromId": {
+                    "type": "integer"
+                },
+                "fromName": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "toId": {
+                    "type": "integer"
+                },
+                "toName": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "description": "最近一次发送或处理请求的时间，重新发送的请求会更新",
+                    "type": "string"
+                }
+            }
+        },
+        "models.FriendView": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "online": {
+                    "description": "由imserver根据在线状态填充",
+                    "type": "boolean"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GroupView": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "member_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Message": {
+            "type": "object",
+            "properties": {
+                "Attachment": {
+                    "description": "附件元数据，读取消息时填充",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attachment"
+                        }
+                    ]
+                },
+                "AttachmentId": {
+                    "description": "引用的附件（图片、语音等），0 表示无附件；附件通过 /api/media/{id} 下载",
+                    "type": "integer"
+                },
+                "ClientMsgId": {
+                    "description": "客户端生成的消息ID，与 FromID 组成唯一索引，用于重发去重",
+                    "type": "string"
+                },
+                "Content": {
+                    "description": "消息内容（二进制数据）",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "ContentType": {
+                    "description": "消息内容类型：1-文本 2-图片 3-语音 4-视频 5-文件",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/im.ContentType"
+                        }
+                    ]
+                },
+                "EditedAt": {
+                    "description": "最后编辑时间",
+                    "type": "string"
+                },
+                "FormId": {
+                    "description": "发送者ID",
+                    "type": "integer"
+                },
+                "RecalledAt": {
+                    "description": "撤回时间，撤回后内容清空",
+                    "type": "string"
+                },
+                "Seq": {
+                    "type": "integer"
+                },
+                "TargetId": {
+                    "description": "接收者ID（私聊为用户ID，群聊为群组ID）",
+                    "type": "integer"
+                },
+                "Type": {
+                    "description": "消息类型：1-私聊 2-群聊",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/im.MessageType"
+                        }
+                    ]
+                },
+                "created_at": {
+                    "description": "创建时间",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "description": "更新时间",
+                    "type": "string"
+                }
+            }
+        },
+        "models.MessagePage": {
+            "type": "object",
+            "properties": {
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Message"
+                    }
+                }
+            }
+        },
+        "models.SearchHit": {
+            "type": "object",
+            "properties": {
+                "highlights": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "message": {
+                    "$ref": "#/definitions/models.Message"
+                },
+                "snippet": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SearchResult": {
+            "type": "object",
+            "properties": {
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "hits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SearchHit"
+                    }
+                }
+            }
+        },
+        "models.SignedURL": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UploadSession": {
+            "type": "object",
+            "properties": {
+                "attachment": {
+                    "description": "秒传命中时直接返回已有附件，不创建会话",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attachment"
+                        }
+                    ]
+                },
+                "chunkSize": {
+                    "description": "除最后一片外每片的字节数",
+                    "type": "integer"
+                },
+                "durationMs": {
+                    "description": "语音时长（毫秒）",
+                    "type": "integer"
+                },
+                "expiresAt": {
+                    "description": "超过该时间未完成的会话及分片被清理",
+                    "type": "string"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "ownerId": {
+                    "type": "integer"
+                },
+                "received": {
+                    "description": "已接收的分片序号",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "sha256": {
+                    "description": "客户端声明的内容哈希，完成时校验",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "文件总字节数",
+                    "type": "integer"
+                },
+                "totalChunks": {
+                    "description": "分片数，序号从0开始",
+                    "type": "integer"
+                },
+                "uploadId": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.AddFriendReq": {
+            "type": "object",
+            "properties": {
+                "friendUsername": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "userID": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.BlockReq": {
+            "type": "object",
+            "properties": {
+                "userID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.CreateGroupReq": {
+            "type": "object",
+            "properties": {
+                "memberIDs": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.DeleteUserReq": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.EditMessageReq": {
+            "type": "object",
+            "required": [
+                "content",
+                "messageId"
+            ],
+            "properties": {
+                "content": {
+                    "description": "base64 编码，与消息的 Content 字段一致",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "messageId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.FriendIDReq": {
+            "type": "object",
+            "properties": {
+                "friendID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.FriendReplyReq": {
+            "type": "object",
+            "properties": {
+                "fromID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.GroupMembersReq": {
+            "type": "object",
+            "properties": {
+                "groupID": {
+                    "type": "integer"
+                },
+                "userIDs": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "service.GroupReq": {
+            "type": "object",
+            "properties": {
+                "groupID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.InitUploadReq": {
+            "type": "object",
+            "required": [
+                "fileName",
+                "sha256",
+                "size"
+            ],
+            "properties": {
+                "chunkSize": {
+                    "description": "期望的分片大小，服务端可能调整",
+                    "type": "integer"
+                },
+                "durationMs": {
+                    "description": "语音时长（毫秒）",
+                    "type": "integer"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "sha256": {
+                    "description": "整个文件的内容哈希",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.PrivacyReq": {
+            "type": "object",
+            "properties": {
+                "friendsOnly": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "service.RecallMessageReq": {
+            "type": "object",
+            "required": [
+                "messageId"
+            ],
+            "properties": {
+                "messageId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.RefreshReq": {
+            "type": "object",
+            "properties": {
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.UpdateConversationReq": {
+            "type": "object",
+            "required": [
+                "targetId",
+                "type"
+            ],
+            "properties": {
+                "archived": {
+                    "type": "boolean"
+                },
+                "clearUnread": {
+                    "type": "boolean"
+                },
+                "hidden": {
+                    "type": "boolean"
+                },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "targetId": {
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/im.MessageType"
+                }
+            }
+        }
     }
 }`
 
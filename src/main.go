@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"github.com/hoyang/imserver/src/router"
 	rpcClient "github.com/hoyang/imserver/src/rpc"
 	"github.com/hoyang/imserver/src/service"
+	"github.com/hoyang/imserver/src/storage"
 	"github.com/hoyang/imserver/src/utils"
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
@@ -47,12 +49,45 @@ func createRedisConn() *redis.Client {
 	return redisDB
 }
 
+// startDebugServer 在内部地址上暴露运行时统计（订阅收发、丢弃、延迟等）
+// 未设置 DEBUG_ADDR 时不启动，不要把该地址映射到公网
+func startDebugServer() {
+	addr := os.Getenv("DEBUG_ADDR")
+	if addr == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Debug server stopped: %v", err)
+		}
+	}()
+}
+
 func main() {
+	// 加载JWT签名密钥
+	if err := utils.InitJWT(); err != nil {
+		log.Fatalf("JWT keys init failed: %v", err)
+	}
+
+	// 加载媒体下载链接签名密钥
+	if err := utils.InitMediaURLSigner(); err != nil {
+		log.Fatalf("Media URL signer init failed: %v", err)
+	}
 
 	grpcClient := initClientPool()
 	redisPubSub := createRedisConn()
-	server := service.NewUserService(grpcClient, redisPubSub)
+	// 令牌吊销列表与总线共用redis
+	utils.SetRevocationStore(redisPubSub)
+	// 媒体文件存储
+	store, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Media storage init failed: %v", err)
+	}
+	server := service.NewUserService(grpcClient, redisPubSub, store)
 	r := router.Router(server)
+	startDebugServer()
 
 	srv := &http.Server{
 		Addr:    ":8080",
@@ -0,0 +1,57 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrInvalidWAV = errors.New("invalid wav file")
+
+// WAVDuration 根据 WAV 文件头中的字节率和数据块大小计算时长
+func WAVDuration(r io.Reader) (time.Duration, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, ErrInvalidWAV
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return 0, ErrInvalidWAV
+	}
+
+	var byteRate uint32
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return 0, ErrInvalidWAV
+		}
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 1<<10 {
+				return 0, ErrInvalidWAV
+			}
+			fmtChunk := make([]byte, size)
+			if _, err := io.ReadFull(r, fmtChunk); err != nil {
+				return 0, ErrInvalidWAV
+			}
+			byteRate = binary.LittleEndian.Uint32(fmtChunk[8:12])
+		case "data":
+			if byteRate == 0 {
+				return 0, ErrInvalidWAV
+			}
+			return time.Duration(uint64(size) * uint64(time.Second) / uint64(byteRate)), nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size)); err != nil {
+				return 0, ErrInvalidWAV
+			}
+		}
+		// 块大小为奇数时有一个填充字节
+		if size%2 == 1 && id != "data" {
+			if _, err := io.CopyN(io.Discard, r, 1); err != nil {
+				return 0, ErrInvalidWAV
+			}
+		}
+	}
+}
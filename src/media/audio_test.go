@@ -0,0 +1,106 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// wavChunk 生成一个 RIFF 块，size 为奇数时补一个填充字节
+func wavChunk(id string, data []byte) []byte {
+	buf := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	buf = append(buf, data...)
+	if len(data)%2 == 1 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// fmtChunk 生成 PCM 格式块
+func fmtChunk(byteRate uint32) []byte {
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint16(data[0:2], 1)    // PCM
+	binary.LittleEndian.PutUint16(data[2:4], 1)    // 单声道
+	binary.LittleEndian.PutUint32(data[4:8], 8000) // 采样率
+	binary.LittleEndian.PutUint32(data[8:12], byteRate)
+	binary.LittleEndian.PutUint16(data[12:14], 2)  // 块对齐
+	binary.LittleEndian.PutUint16(data[14:16], 16) // 位深
+	return wavChunk("fmt ", data)
+}
+
+func wavFile(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+func TestWAVDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    []byte
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name: "一秒",
+			file: wavFile(fmtChunk(16000), wavChunk("data", make([]byte, 16000))),
+			want: time.Second,
+		},
+		{
+			name: "只有文件头中的数据长度",
+			file: wavFile(fmtChunk(16000), []byte("data\x40\x1f\x00\x00")),
+			want: 500 * time.Millisecond,
+		},
+		{
+			name: "跳过奇数长度的其他块",
+			file: wavFile(wavChunk("LIST", []byte("abc")), fmtChunk(8000), wavChunk("data", make([]byte, 2000))),
+			want: 250 * time.Millisecond,
+		},
+		{
+			name:    "数据块在格式块之前",
+			file:    wavFile(wavChunk("data", make([]byte, 16)), fmtChunk(16000)),
+			wantErr: true,
+		},
+		{
+			name:    "格式块过短",
+			file:    wavFile(wavChunk("fmt ", make([]byte, 8)), wavChunk("data", nil)),
+			wantErr: true,
+		},
+		{
+			name:    "缺少数据块",
+			file:    wavFile(fmtChunk(16000)),
+			wantErr: true,
+		},
+		{
+			name:    "不是WAV",
+			file:    append([]byte("RIFF\x00\x00\x00\x00AVI "), fmtChunk(16000)...),
+			wantErr: true,
+		},
+		{
+			name:    "文件头不完整",
+			file:    []byte("RIFF"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WAVDuration(bytes.NewReader(tt.file))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidWAV) {
+					t.Fatalf("err = %v, want ErrInvalidWAV", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("duration = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif" // 注册解码器
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+// 解码生成缩略图的最大像素数，防止解压炸弹
+const maxDecodePixels = 50_000_000
+
+var ErrImageTooLarge = errors.New("image too large to decode")
+
+// ImageInfo 读取图片尺寸，不解码像素数据
+func ImageInfo(r io.Reader) (width, height int, err error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// Decode 解码图片，像素数超过上限时返回 ErrImageTooLarge
+func Decode(r io.ReadSeeker) (image.Image, error) {
+	width, height, err := ImageInfo(r)
+	if err != nil {
+		return nil, err
+	}
+	if width*height > maxDecodePixels {
+		return nil, ErrImageTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// Thumbnail 按最长边等比缩小到 maxSide 以内（不放大），区域平均采样
+// 透明部分与白色背景合成，结果不含透明通道，可直接编码为 JPEG
+func Thumbnail(src image.Image, maxSide int) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w >= h && w > maxSide {
+		tw, th = maxSide, max(1, h*maxSide/w)
+	} else if h > w && h > maxSide {
+		tw, th = max(1, w*maxSide/h), maxSide
+	}
+
+	at := rgbaFunc(src)
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		sy0 := b.Min.Y + y*h/th
+		sy1 := max(b.Min.Y+(y+1)*h/th, sy0+1)
+		for x := 0; x < tw; x++ {
+			sx0 := b.Min.X + x*w/tw
+			sx1 := max(b.Min.X+(x+1)*w/tw, sx0+1)
+
+			var sr, sg, sb, sa, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					r, g, b, a := at(sx, sy)
+					sr, sg, sb, sa = sr+uint64(r), sg+uint64(g), sb+uint64(b), sa+uint64(a)
+					n++
+				}
+			}
+			// RGBA() 为预乘值，叠加白色背景：c + (1 - a)
+			bg := 0xffff - sa/n
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(sr/n + bg),
+				G: uint16(sg/n + bg),
+				B: uint16(sb/n + bg),
+				A: 0xffff,
+			})
+		}
+	}
+	return dst
+}
+
+// rgbaFunc 返回读取像素预乘 RGBA 值的函数
+// 常见的解码结果直接读取像素数据，避免 At() 每个像素分配一个 color.Color
+func rgbaFunc(src image.Image) func(x, y int) (r, g, b, a uint32) {
+	switch img := src.(type) {
+	case *image.YCbCr: // JPEG
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			yi, ci := img.YOffset(x, y), img.COffset(x, y)
+			return color.YCbCr{Y: img.Y[yi], Cb: img.Cb[ci], Cr: img.Cr[ci]}.RGBA()
+		}
+	case *image.RGBA: // 缩略图自身，由中图生成小图时
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			p := img.Pix[img.PixOffset(x, y):]
+			return uint32(p[0]) * 0x101, uint32(p[1]) * 0x101, uint32(p[2]) * 0x101, uint32(p[3]) * 0x101
+		}
+	case *image.NRGBA: // PNG
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			p := img.Pix[img.PixOffset(x, y):]
+			return color.NRGBA{R: p[0], G: p[1], B: p[2], A: p[3]}.RGBA()
+		}
+	}
+	return func(x, y int) (uint32, uint32, uint32, uint32) {
+		return src.At(x, y).RGBA()
+	}
+}
+
+// EncodeJPEG 将缩略图编码为 JPEG
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
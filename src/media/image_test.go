@@ -0,0 +1,125 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestImageInfo(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 64, 48))); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+		wantErr       bool
+	}{
+		{name: "PNG", data: buf.Bytes(), width: 64, height: 48},
+		// 只有文件头的 GIF 也能读出尺寸
+		{name: "GIF文件头", data: []byte("GIF89a\x20\x03\x58\x02\x00\x00\x00"), width: 800, height: 600},
+		{name: "不是图片", data: []byte("hello"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h, err := ImageInfo(bytes.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if w != tt.width || h != tt.height {
+				t.Errorf("size = %dx%d, want %dx%d", w, h, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestDecodeTooLarge(t *testing.T) {
+	// 65535x65535 的 GIF 文件头，未解码像素就应拒绝
+	_, err := Decode(bytes.NewReader([]byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")))
+	if !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("err = %v, want ErrImageTooLarge", err)
+	}
+}
+
+func TestThumbnailSize(t *testing.T) {
+	tests := []struct {
+		w, h, maxSide int
+		tw, th        int
+	}{
+		{w: 100, h: 50, maxSide: 40, tw: 40, th: 20},
+		{w: 50, h: 100, maxSide: 40, tw: 20, th: 40},
+		{w: 30, h: 20, maxSide: 40, tw: 30, th: 20}, // 不放大
+		{w: 1000, h: 1, maxSide: 100, tw: 100, th: 1},
+		{w: 1, h: 1000, maxSide: 100, tw: 1, th: 100},
+	}
+	for _, tt := range tests {
+		got := Thumbnail(image.NewRGBA(image.Rect(0, 0, tt.w, tt.h)), tt.maxSide).Bounds()
+		if got.Dx() != tt.tw || got.Dy() != tt.th {
+			t.Errorf("Thumbnail(%dx%d, %d) = %dx%d, want %dx%d", tt.w, tt.h, tt.maxSide, got.Dx(), got.Dy(), tt.tw, tt.th)
+		}
+	}
+}
+
+func TestThumbnailPixels(t *testing.T) {
+	fill := func(img interface{ Set(x, y int, c color.Color) }, c color.Color) {
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				img.Set(x, y, c)
+			}
+		}
+	}
+	rect := image.Rect(0, 0, 8, 8)
+	newImage := func(kind string, c color.Color) image.Image {
+		switch kind {
+		case "RGBA":
+			img := image.NewRGBA(rect)
+			fill(img, c)
+			return img
+		case "NRGBA":
+			img := image.NewNRGBA(rect)
+			fill(img, c)
+			return img
+		case "YCbCr":
+			img := image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)
+			y, cb, cr := color.RGBToYCbCr(c.(color.NRGBA).R, c.(color.NRGBA).G, c.(color.NRGBA).B)
+			for i := range img.Y {
+				img.Y[i] = y
+			}
+			for i := range img.Cb {
+				img.Cb[i], img.Cr[i] = cb, cr
+			}
+			return img
+		}
+		// 其他类型走 At() 通用路径
+		img := image.NewNRGBA64(rect)
+		fill(img, c)
+		return img
+	}
+
+	tests := []struct {
+		name  string
+		kind  string
+		color color.NRGBA
+		want  color.RGBA
+	}{
+		{name: "不透明", kind: "NRGBA", color: color.NRGBA{R: 200, G: 100, B: 50, A: 255}, want: color.RGBA{R: 200, G: 100, B: 50, A: 255}},
+		{name: "预乘", kind: "RGBA", color: color.NRGBA{R: 200, G: 100, B: 50, A: 255}, want: color.RGBA{R: 200, G: 100, B: 50, A: 255}},
+		{name: "通用路径", kind: "NRGBA64", color: color.NRGBA{R: 200, G: 100, B: 50, A: 255}, want: color.RGBA{R: 200, G: 100, B: 50, A: 255}},
+		{name: "JPEG", kind: "YCbCr", color: color.NRGBA{R: 128, G: 128, B: 128, A: 255}, want: color.RGBA{R: 128, G: 128, B: 128, A: 255}},
+		{name: "全透明合成白色", kind: "NRGBA", color: color.NRGBA{A: 0}, want: color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+		{name: "半透明黑色合成灰色", kind: "NRGBA", color: color.NRGBA{A: 128}, want: color.RGBA{R: 127, G: 127, B: 127, A: 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb := Thumbnail(newImage(tt.kind, tt.color), 4)
+			if got := thumb.RGBAAt(1, 2); got != tt.want {
+				t.Errorf("pixel = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
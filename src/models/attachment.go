@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Attachment 媒体附件元数据，文件内容保存在存储后端
+// 相同内容的文件共用一个存储对象；同一用户重复上传相同内容时复用已有记录
+type Attachment struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	OwnerID    uint64    `gorm:"index:idx_owner_sha256;not null" json:"ownerId"`                    // 上传者ID
+	FileName   string    `gorm:"type:varchar(255);not null" json:"fileName"`                        // 原始文件名
+	MimeType   string    `gorm:"type:varchar(127);not null" json:"mimeType"`                        // 服务端检测的MIME类型
+	Size       int64     `gorm:"not null" json:"size"`                                              // 字节数
+	SHA256     string    `gorm:"type:char(64);index;index:idx_owner_sha256;not null" json:"sha256"` // 内容哈希（十六进制）
+	StorageKey string    `gorm:"type:varchar(255);not null" json:"-"`                               // 存储后端中的对象键
+	CreatedAt  time.Time `gorm:"not null" json:"createdAt"`
+
+	// 客户端据此在下载前排版消息气泡
+	Width         int32 `gorm:"not null;default:0" json:"width,omitempty"`      // 图片宽度（像素）
+	Height        int32 `gorm:"not null;default:0" json:"height,omitempty"`     // 图片高度（像素）
+	DurationMs    int64 `gorm:"not null;default:0" json:"durationMs,omitempty"` // 语音时长（毫秒）
+	HasThumbnails bool  `gorm:"not null;default:false" json:"hasThumbnails"`    // 缩略图通过 /api/media/{id}/thumb?size= 获取
+}
+
+// TableName 指定表名
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// UploadSession 分片上传会话，客户端断线后查询 Received 续传缺失的分片
+type UploadSession struct {
+	ID          string      `json:"uploadId,omitempty"`
+	OwnerID     uint64      `json:"ownerId"`
+	FileName    string      `json:"fileName"`
+	Size        int64       `json:"size"`                 // 文件总字节数
+	SHA256      string      `json:"sha256"`               // 客户端声明的内容哈希，完成时校验
+	ChunkSize   int64       `json:"chunkSize"`            // 除最后一片外每片的字节数
+	TotalChunks int         `json:"totalChunks"`          // 分片数，序号从0开始
+	DurationMs  int64       `json:"durationMs,omitempty"` // 语音时长（毫秒）
+	Received    []int       `json:"received"`             // 已接收的分片序号
+	ExpiresAt   time.Time   `json:"expiresAt"`            // 超过该时间未完成的会话及分片被清理
+	Attachment  *Attachment `json:"attachment,omitempty"` // 秒传命中时直接返回已有附件，不创建会话
+}
+
+// SignedURL 附件的签名下载链接（相对路径）
+type SignedURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
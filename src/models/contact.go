@@ -6,10 +6,18 @@ import (
 	"gorm.io/gorm"
 )
 
+// 好友关系状态：UserID 向 FriendID 发出请求时为 pending，对方接受后双向均为 accepted
+const (
+	ContactPending  = "pending"
+	ContactAccepted = "accepted"
+	ContactRejected = "rejected"
+)
+
 type Contact struct {
 	UserID    uint64 `gorm:"primaryKey;index" json:"user_id"`
 	FriendID  uint64 `gorm:"primaryKey;index" json:"friend_id"`
 	Status    string `gorm:"default:'pending';not null" json:"status"`
+	Note      string `gorm:"type:varchar(255)" json:"note"` // 好友请求附言
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
@@ -18,3 +26,14 @@ type Contact struct {
 func (s *Contact) TableName() string {
 	return "user_friends"
 }
+
+// Block 拉黑关系：UserID 拉黑了 BlockedID
+type Block struct {
+	UserID    uint64 `gorm:"primaryKey" json:"user_id"`
+	BlockedID uint64 `gorm:"primaryKey;index" json:"blocked_id"`
+	CreatedAt time.Time
+}
+
+func (b *Block) TableName() string {
+	return "user_blocks"
+}
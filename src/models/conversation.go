@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+	"unicode/utf8"
+
+	im "github.com/hoyang/imserver/src/proto"
+)
+
+// 会话列表中消息预览的最大字符数
+const conversationPreviewLen = 50
+
+// RecalledPreview 已撤回消息在会话列表中的预览
+const RecalledPreview = "[消息已撤回]"
+
+// Conversation 用户的会话索引，每个用户与每个私聊对象或群组一条，随消息存储更新
+type Conversation struct {
+	ID            uint64         `gorm:"primaryKey;autoIncrement" json:"-"`
+	UserID        uint64         `gorm:"not null;uniqueIndex:uk_user_conv;index:idx_user_active" json:"-"`
+	Type          im.MessageType `gorm:"not null;uniqueIndex:uk_user_conv" json:"type"`     // 会话类型：1-私聊 2-群聊
+	TargetID      uint64         `gorm:"not null;uniqueIndex:uk_user_conv" json:"targetId"` // 私聊为对方用户ID，群聊为群组ID
+	LastMessageID uint64         `gorm:"not null;index" json:"lastMessageId"`
+	LastSenderID  uint64         `gorm:"not null" json:"lastSenderId"`
+	LastPreview   string         `gorm:"type:varchar(255);not null" json:"lastPreview"` // 最后一条消息的预览
+	LastActiveAt  time.Time      `gorm:"not null;index:idx_user_active" json:"lastActiveAt"`
+	UnreadCount   uint32         `gorm:"not null;default:0" json:"unreadCount"`
+	Pinned        bool           `gorm:"not null;default:false" json:"pinned"`   // 置顶
+	Hidden        bool           `gorm:"not null;default:false" json:"hidden"`   // 从列表中隐藏，收到新消息后重新显示
+	Archived      bool           `gorm:"not null;default:false" json:"archived"` // 归档，只在归档列表中显示
+	UpdatedAt     time.Time      `json:"-"`
+}
+
+// TableName 指定表名
+func (Conversation) TableName() string {
+	return "conversations"
+}
+
+// ConversationView 会话列表项，Name 为对方用户名或群名称
+type ConversationView struct {
+	Conversation
+	Name string `json:"name"`
+}
+
+// MessagePreview 生成会话列表中的消息预览：文本截取开头，其他类型显示类型名
+// 非文本消息的内容可能是任意字节，不能作为文本展示
+func MessagePreview(contentType im.ContentType, content []byte) string {
+	switch contentType {
+	case im.ContentType_TEXT:
+	case im.ContentType_PICUTRE:
+		return "[图片]"
+	case im.ContentType_VOICE:
+		return "[语音]"
+	default:
+		return "[消息]"
+	}
+	if len(content) == 0 || !utf8.Valid(content) {
+		return "[消息]"
+	}
+	text := string(content)
+	if utf8.RuneCountInString(text) > conversationPreviewLen {
+		text = string([]rune(text)[:conversationPreviewLen]) + "…"
+	}
+	return text
+}
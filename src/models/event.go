@@ -0,0 +1,159 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	im "github.com/hoyang/imserver/src/proto"
+)
+
+// 事件类型（WebSocket 帧与 redis 总线共用）
+const (
+	ActionMessage  = "message"  // 聊天消息
+	ActionRead     = "read"     // 客户端上报已读
+	ActionReceipt  = "receipt"  // 已读回执
+	ActionAck      = "ack"      // 客户端确认收到消息
+	ActionSent     = "sent"     // 服务端确认消息已发送
+	ActionFailed   = "failed"   // 消息发送失败
+	ActionSync     = "sync"     // 客户端请求补齐序列号缺口
+	ActionKicked   = "kicked"   // 会话被同类设备的新登录挤下线
+	ActionPresence = "presence" // 好友上下线通知
+	ActionRecall   = "recall"   // 消息被撤回
+	ActionEdit     = "edit"     // 消息被编辑
+
+	ActionFriendRequest  = "friend_request"  // 收到好友请求
+	ActionFriendAccepted = "friend_accepted" // 好友请求被接受
+	ActionFriendRejected = "friend_rejected" // 好友请求被拒绝
+)
+
+// 发送失败原因
+const (
+	SendErrInvalid     = "invalid"     // 消息格式或参数错误
+	SendErrForbidden   = "forbidden"   // 无权发送
+	SendErrUnavailable = "unavailable" // 存储服务不可用，可重试
+)
+
+// Envelope redis 总线上传递的事件
+type Envelope struct {
+	Action    string          `json:"action"`
+	Receivers []uint64        `json:"receivers"`        // 接收实例上需要投递的用户ID
+	Key       string          `json:"key,omitempty"`    // 排序键，相同键的事件按顺序分发
+	Origin    string          `json:"origin,omitempty"` // 发送消息的设备ID，同步给发送者其他设备时跳过
+	SentAt    int64           `json:"ts"`               // 发布时间（Unix毫秒），用于统计延迟
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewEnvelope 创建总线事件
+func NewEnvelope(action string, receivers []uint64, data any) (*Envelope, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{Action: action, Receivers: receivers, SentAt: time.Now().UnixMilli(), Data: raw}, nil
+}
+
+func EnvelopeFromString(jsonStr string) (Envelope, error) {
+	var env Envelope
+	err := json.Unmarshal([]byte(jsonStr), &env)
+	return env, err
+}
+
+func (e *Envelope) String() string {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Sprintf("Envelope{error: %v}", err)
+	}
+	return string(data)
+}
+
+// ReadEvent 客户端上报的已读事件
+// 指定 MessageIDs 时按消息标记；否则标记与 PeerID 会话中不超过 UpToID 的消息
+type ReadEvent struct {
+	Action     string   `json:"action"`
+	MessageIDs []uint64 `json:"messageIds,omitempty"`
+	PeerID     uint64   `json:"peerId,omitempty"`
+	UpToID     uint64   `json:"upToId,omitempty"`
+}
+
+// ReadReceipt 推送给原消息发送者的已读回执
+type ReadReceipt struct {
+	Action     string    `json:"action"`
+	ReaderID   uint64    `json:"readerId"`
+	MessageIDs []uint64  `json:"messageIds"`
+	ReadAt     time.Time `json:"readAt"`
+}
+
+// AckEvent 客户端对已推送消息的确认
+type AckEvent struct {
+	Action     string   `json:"action"`
+	MessageIDs []uint64 `json:"messageIds"`
+}
+
+// SentAck 服务端回复给发送者的发送确认
+type SentAck struct {
+	Action      string    `json:"action"`
+	ClientMsgID string    `json:"clientMsgId,omitempty"`
+	MessageID   uint64    `json:"messageId"`
+	Seq         uint64    `json:"seq"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Duplicate   bool      `json:"duplicate,omitempty"` // 重复发送，消息未再次投递
+}
+
+// SendFailure 消息发送失败时回复给发送者的帧
+type SendFailure struct {
+	Action      string `json:"action"`
+	ClientMsgID string `json:"clientMsgId,omitempty"`
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+}
+
+func NewSendFailure(clientMsgID, code, message string) *SendFailure {
+	return &SendFailure{Action: ActionFailed, ClientMsgID: clientMsgID, Code: code, Message: message}
+}
+
+// SyncEvent 客户端请求补齐会话中 [FromSeq, ToSeq] 区间的消息
+type SyncEvent struct {
+	Action   string         `json:"action"`
+	Type     im.MessageType `json:"Type"`
+	TargetID uint64         `json:"TargetId"`
+	FromSeq  uint64         `json:"fromSeq"`
+	ToSeq    uint64         `json:"toSeq,omitempty"`
+	Limit    int32          `json:"limit,omitempty"`
+}
+
+// Kicked 通知被挤下线的设备，同时作为总线上的踢线事件
+type Kicked struct {
+	Action   string `json:"action"`
+	DeviceID string `json:"deviceId"`
+	Reason   string `json:"reason"`
+	TokenID  string `json:"tokenId,omitempty"` // 非空时只踢下使用该令牌建立的连接
+}
+
+// PresenceEvent 推送给在线好友的上下线通知
+type PresenceEvent struct {
+	Action   string    `json:"action"`
+	UserID   uint64    `json:"userId"`
+	Online   bool      `json:"online"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// FriendEvent 好友请求及处理结果通知，UserID 为对方用户
+type FriendEvent struct {
+	Action   string `json:"action"`
+	UserID   uint64 `json:"userId"`
+	Username string `json:"username"`
+	Note     string `json:"note,omitempty"`
+}
+
+// MessageChange 推送给会话参与者的消息撤回或编辑事件，客户端据此原地更新消息
+type MessageChange struct {
+	Action    string         `json:"action"`
+	MessageID uint64         `json:"messageId"`
+	FromID    uint64         `json:"FormId"`
+	TargetID  uint64         `json:"TargetId"`
+	Type      im.MessageType `json:"Type"`
+	Seq       uint64         `json:"Seq"`
+	Content   []byte         `json:"Content,omitempty"` // 编辑后的内容，撤回时为空
+	ChangedAt time.Time      `json:"changedAt"`
+}
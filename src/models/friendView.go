@@ -1,8 +1,21 @@
 package models
 
+import "time"
+
 // FriendView 用于查询的好友视图模型
 type FriendView struct {
 	ID       uint   `json:"id"`
 	Username string `json:"username"`
-	Online   bool   `json:"online"`
+	Online   bool   `json:"online"` // 由imserver根据在线状态填充
+}
+
+// FriendRequestView 好友请求视图模型
+type FriendRequestView struct {
+	FromID    uint64    `json:"fromId"`
+	FromName  string    `json:"fromName"`
+	ToID      uint64    `json:"toId"`
+	ToName    string    `json:"toName"`
+	Note      string    `json:"note"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updatedAt"` // 最近一次发送或处理请求的时间，重新发送的请求会更新
 }
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 群成员角色
+const (
+	GroupRoleOwner  = "owner"  // 群主
+	GroupRoleMember = "member" // 普通成员
+)
+
+// Group 群组模型
+type Group struct {
+	gorm.Model
+	Name    string `gorm:"type:varchar(255);not null" json:"name"`
+	OwnerID uint64 `gorm:"index;not null" json:"owner_id"` // 群主ID
+}
+
+// GroupMember 群成员关系
+type GroupMember struct {
+	GroupID   uint64    `gorm:"primaryKey" json:"group_id"`
+	UserID    uint64    `gorm:"primaryKey;index" json:"user_id"`
+	Role      string    `gorm:"type:varchar(20);default:'member';not null" json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名（groups 为 MySQL 保留字）
+func (Group) TableName() string {
+	return "im_groups"
+}
+
+// TableName 指定表名
+func (GroupMember) TableName() string {
+	return "group_members"
+}
+
+// GroupView 群组视图模型
+type GroupView struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   uint64    `json:"owner_id"`
+	MemberIDs []uint64  `json:"member_ids"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -20,7 +20,8 @@ type IMUser struct {
 	Identity      string     `json:"identity,omitempty" gorm:"type:varchar(100)"`
 	Device        string     `json:"device,omitempty" gorm:"type:varchar(100)"`
 	IsLogout      bool       `json:"is_logout" gorm:"default:true"`
-	Salt          string
+	Salt          string     // 仅旧的MD5密码哈希使用，新哈希自带盐
+	FriendsOnly   bool       `json:"friends_only" gorm:"default:false"` // 只接收好友的私聊消息
 
 	//好友关系 - 引用多对多
 	Contacts []*Contact `gorm:"many2many:user_friends;joinForeignKey:firend_id;joinReferences:user_id"`
@@ -15,24 +15,86 @@ const (
 	MessageTypeGroup   = 2 // 群聊消息
 )
 
+// DeletedUserID 已注销用户保留消息的发送者ID
+const DeletedUserID = 0
+
 // Message 消息模型
 type Message struct {
 	ID          uint64         `gorm:"primaryKey;autoIncrement" json:"id"`
-	FromID      uint64         `gorm:"index;not null" json:"FormId"`      // 发送者ID
-	ToID        uint64         `gorm:"index;not null" json:"TargetId"`    // 接收者ID（私聊为用户ID，群聊为群组ID）
-	Type        im.MessageType `gorm:"not null" json:"Type"`              // 消息类型：1-私聊 2-群聊
-	ContentType im.ContentType `gorm:"not null" json:"ContentType"`       // 消息内容类型：1-文本 2-图片 3-语音 4-视频 5-文件
-	Content     []byte         `gorm:"type:blob;not null" json:"Content"` // 消息内容（二进制数据）
-	CreatedAt   time.Time      `gorm:"not null" json:"created_at"`        // 创建时间
-	UpdatedAt   time.Time      `gorm:"not null" json:"updated_at"`        // 更新时间
+	FromID      uint64         `gorm:"index;not null;uniqueIndex:uk_from_client_msg" json:"FormId"` // 发送者ID
+	ToID        uint64         `gorm:"index;not null" json:"TargetId"`                              // 接收者ID（私聊为用户ID，群聊为群组ID）
+	Type        im.MessageType `gorm:"not null" json:"Type"`                                        // 消息类型：1-私聊 2-群聊
+	ContentType im.ContentType `gorm:"not null" json:"ContentType"`                                 // 消息内容类型：1-文本 2-图片 3-语音 4-视频 5-文件
+	Content     []byte         `gorm:"type:blob;not null" json:"Content"`                           // 消息内容（二进制数据）
+	CreatedAt   time.Time      `gorm:"not null" json:"created_at"`                                  // 创建时间
+	UpdatedAt   time.Time      `gorm:"not null" json:"updated_at"`                                  // 更新时间
+
+	// 客户端生成的消息ID，与 FromID 组成唯一索引，用于重发去重
+	ClientMsgID string `gorm:"type:varchar(64);default:null;uniqueIndex:uk_from_client_msg" json:"ClientMsgId,omitempty"`
+
+	// 会话标识与会话内序列号，客户端据此检测缺口
+	ConvKey string `gorm:"type:varchar(64);default:null;uniqueIndex:uk_conv_seq" json:"-"`
+	Seq     uint64 `gorm:"uniqueIndex:uk_conv_seq" json:"Seq"`
+
+	RecalledAt *time.Time `json:"RecalledAt,omitempty"` // 撤回时间，撤回后内容清空
+	EditedAt   *time.Time `json:"EditedAt,omitempty"`   // 最后编辑时间
+
+	// 引用的附件（图片、语音等），0 表示无附件；附件通过 /api/media/{id} 下载
+	AttachmentID uint64      `gorm:"index;not null;default:0" json:"AttachmentId,omitempty"`
+	Attachment   *Attachment `gorm:"-" json:"Attachment,omitempty"` // 附件元数据，读取消息时填充
+}
+
+// MessageEdit 消息编辑历史，保存每次编辑前的内容
+type MessageEdit struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID uint64    `gorm:"index;not null" json:"message_id"`
+	Content   []byte    `gorm:"type:blob;not null" json:"content"` // 编辑前的内容
+	EditedAt  time.Time `gorm:"not null" json:"edited_at"`
+}
+
+// MessagePage 分页查询的消息，HasMore 表示翻页方向上还有更多消息
+type MessagePage struct {
+	Messages []Message `json:"messages"`
+	HasMore  bool      `json:"hasMore"`
+}
+
+// SearchHit 消息检索结果，Highlights 为关键词在 Snippet 中的 [start, end) 区间（按 Unicode 字符计）
+type SearchHit struct {
+	Message    Message    `json:"message"`
+	Snippet    string     `json:"snippet"`
+	Highlights [][2]int32 `json:"highlights"`
+}
+
+// SearchResult 消息检索结果页，按时间倒序
+type SearchResult struct {
+	Hits    []SearchHit `json:"hits"`
+	HasMore bool        `json:"hasMore"`
+}
+
+// ConversationSeq 会话序列号分配记录
+type ConversationSeq struct {
+	ConvKey string `gorm:"primaryKey;type:varchar(64)"`
+	Seq     uint64 `gorm:"not null"`
+}
+
+// ConversationKey 生成会话标识：私聊与双方顺序无关，群聊为群组ID
+func ConversationKey(msgType im.MessageType, fromID, toID uint64) string {
+	if msgType == MessageTypeGroup {
+		return fmt.Sprintf("g:%d", toID)
+	}
+	if fromID > toID {
+		fromID, toID = toID, fromID
+	}
+	return fmt.Sprintf("p:%d:%d", fromID, toID)
 }
 
 // UnreadMessage 未读消息记录（仅用于单聊）
 type UnreadMessage struct {
-	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID    uint64    `gorm:"index;not null" json:"user_id"`    // 用户ID
-	MessageID uint64    `gorm:"index;not null" json:"message_id"` // 消息ID
-	CreatedAt time.Time `gorm:"not null" json:"created_at"`       // 创建时间
+	ID          uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint64     `gorm:"index;not null" json:"user_id"`    // 用户ID
+	MessageID   uint64     `gorm:"index;not null" json:"message_id"` // 消息ID
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`           // 客户端确认送达时间，为空表示待投递
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`       // 创建时间
 }
 
 // TableName 指定表名
@@ -40,11 +102,21 @@ func (Message) TableName() string {
 	return "messages"
 }
 
+// TableName 指定表名
+func (MessageEdit) TableName() string {
+	return "message_edits"
+}
+
 // TableName 指定表名
 func (UnreadMessage) TableName() string {
 	return "unread_messages"
 }
 
+// TableName 指定表名
+func (ConversationSeq) TableName() string {
+	return "conversation_seqs"
+}
+
 func MessageFromString(jsonStr string) (Message, error) {
 	var msg Message
 	err := json.Unmarshal([]byte(jsonStr), &msg)
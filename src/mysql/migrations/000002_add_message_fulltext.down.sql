@@ -0,0 +1,2 @@
+-- 删除全文索引及检索列
+ALTER TABLE messages DROP INDEX ft_messages_search_text, DROP COLUMN search_text;
@@ -0,0 +1,8 @@
+-- 消息全文检索：文本消息内容的存储生成列，撤回时内容已清空，不会被检索到
+-- ngram 分词，支持中文；分词长度由 ngram_token_size 决定（默认2）
+-- 迁移器每个文件只执行一次 Exec，列和索引须在同一条语句中添加
+ALTER TABLE messages
+    ADD COLUMN search_text TEXT
+        GENERATED ALWAYS AS (IF(content_type = 0, CONVERT(content USING utf8mb4), NULL)) STORED
+        COMMENT '全文检索内容（仅文本消息）',
+    ADD FULLTEXT INDEX ft_messages_search_text (search_text) WITH PARSER ngram;
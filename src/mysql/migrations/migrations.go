@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS 内嵌的迁移文件，dbproxy 启动时执行，镜像中无需另外携带迁移目录
+//
+//go:embed *.sql
+var FS embed.FS
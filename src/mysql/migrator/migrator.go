@@ -2,6 +2,7 @@ package migrator
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -20,8 +21,13 @@ func NewMigrator(db *gorm.DB) *Migrator {
 	return &Migrator{db: db}
 }
 
-// Migrate 执行数据库迁移
+// Migrate 执行目录中的数据库迁移
 func (m *Migrator) Migrate(migrationsPath string) error {
+	return m.MigrateFS(os.DirFS(migrationsPath))
+}
+
+// MigrateFS 执行文件系统中的数据库迁移，迁移文件位于 fsys 根目录
+func (m *Migrator) MigrateFS(fsys fs.FS) error {
 	// 1. 创建迁移记录表
 	if err := m.createMigrationTable(); err != nil {
 		return fmt.Errorf("创建迁移记录表失败: %v", err)
@@ -34,7 +40,7 @@ func (m *Migrator) Migrate(migrationsPath string) error {
 	}
 
 	// 3. 读取迁移文件
-	files, err := os.ReadDir(migrationsPath)
+	files, err := fs.ReadDir(fsys, ".")
 	if err != nil {
 		return fmt.Errorf("读取迁移文件目录失败: %v", err)
 	}
@@ -56,7 +62,7 @@ func (m *Migrator) Migrate(migrationsPath string) error {
 		}
 
 		// 读取迁移文件内容
-		content, err := os.ReadFile(filepath.Join(migrationsPath, file))
+		content, err := fs.ReadFile(fsys, file)
 		if err != nil {
 			return fmt.Errorf("读取迁移文件 %s 失败: %v", file, err)
 		}
@@ -24,10 +24,30 @@ const _ = grpc.SupportPackageIsVersion7
 type MessageServiceClient interface {
 	// 存储消息
 	StoreMessage(ctx context.Context, in *StoreMessageRequest, opts ...grpc.CallOption) (*StoreMessageResponse, error)
+	// 按 client_msg_id 查找已存储的消息，用于重发时跳过耗时的预处理；不存在时返回 NotFound
+	FindMessageByClientMsgID(ctx context.Context, in *FindMessageByClientMsgIDRequest, opts ...grpc.CallOption) (*StoreMessageResponse, error)
 	// 获取未读消息（仅用于单聊）
 	GetUnreadMessages(ctx context.Context, in *GetUnreadMessagesRequest, opts ...grpc.CallOption) (*GetUnreadMessagesResponse, error)
 	// 获取群聊消息（分页）
 	GetGroupMessages(ctx context.Context, in *GetGroupMessagesRequest, opts ...grpc.CallOption) (*GetGroupMessagesResponse, error)
+	// 标记消息已读（仅用于单聊）
+	MarkRead(ctx context.Context, in *MarkReadRequest, opts ...grpc.CallOption) (*MarkReadResponse, error)
+	// 确认消息已送达客户端（仅用于单聊）
+	AckMessages(ctx context.Context, in *AckMessagesRequest, opts ...grpc.CallOption) (*AckMessagesResponse, error)
+	// 按会话序列号区间获取消息，用于客户端补齐缺口
+	GetMessagesBySeq(ctx context.Context, in *GetMessagesBySeqRequest, opts ...grpc.CallOption) (*GetMessagesBySeqResponse, error)
+	// 获取两个用户之间的私聊历史（按消息ID游标分页）
+	GetConversationMessages(ctx context.Context, in *GetConversationMessagesRequest, opts ...grpc.CallOption) (*GetConversationMessagesResponse, error)
+	// 获取用户的会话列表
+	ListConversations(ctx context.Context, in *ListConversationsRequest, opts ...grpc.CallOption) (*ListConversationsResponse, error)
+	// 更新会话的置顶/隐藏/归档标记，或清零未读数
+	UpdateConversation(ctx context.Context, in *UpdateConversationRequest, opts ...grpc.CallOption) (*UpdateConversationResponse, error)
+	// 撤回消息（仅发送者，且在撤回时限内）
+	RecallMessage(ctx context.Context, in *RecallMessageRequest, opts ...grpc.CallOption) (*MessageChangeResponse, error)
+	// 编辑消息（仅发送者），编辑前的内容保存为编辑历史
+	EditMessage(ctx context.Context, in *EditMessageRequest, opts ...grpc.CallOption) (*MessageChangeResponse, error)
+	// 在用户参与的会话中全文检索文本消息，按时间倒序（按消息ID游标分页）
+	SearchMessages(ctx context.Context, in *SearchMessagesRequest, opts ...grpc.CallOption) (*SearchMessagesResponse, error)
 }
 
 type messageServiceClient struct {
@@ -47,6 +67,15 @@ func (c *messageServiceClient) StoreMessage(ctx context.Context, in *StoreMessag
 	return out, nil
 }
 
+func (c *messageServiceClient) FindMessageByClientMsgID(ctx context.Context, in *FindMessageByClientMsgIDRequest, opts ...grpc.CallOption) (*StoreMessageResponse, error) {
+	out := new(StoreMessageResponse)
+	err := c.cc.Invoke(ctx, "/im.MessageService/FindMessageByClientMsgID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *messageServiceClient) GetUnreadMessages(ctx context.Context, in *GetUnreadMessagesRequest, opts ...grpc.CallOption) (*GetUnreadMessagesResponse, error) {
 	out := new(GetUnreadMessagesResponse)
 	err := c.cc.Invoke(ctx, "/im.MessageService/GetUnreadMessages", in, out, opts...)
@@ -65,16 +94,117 @@ func (c *messageServiceClient) GetGroupMessages(ctx context.Context, in *GetGrou
 	return out, nil
 }
 
+func (c *messageServiceClient) MarkRead(ctx context.Context, in *MarkReadRequest, opts ...grpc.CallOption) (*MarkReadResponse, error) {
+	out := new(MarkReadResponse)
+	err := c.cc.Invoke(ctx, "/im.MessageService/MarkRead", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) AckMessages(ctx context.Context, in *AckMessagesRequest, opts ...grpc.CallOption) (*AckMessagesResponse, error) {
+	out := new(AckMessagesResponse)
+	err := c.cc.Invoke(ctx, "/im.MessageService/AckMessages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) GetMessagesBySeq(ctx context.Context, in *GetMessagesBySeqRequest, opts ...grpc.CallOption) (*GetMessagesBySeqResponse, error) {
+	out := new(GetMessagesBySeqResponse)
+	err := c.cc.Invoke(ctx, "/im.MessageService/GetMessagesBySeq", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) GetConversationMessages(ctx context.Context, in *GetConversationMessagesRequest, opts ...grpc.CallOption) (*GetConversationMessagesResponse, error) {
+	out := new(GetConversationMessagesResponse)
+	err := c.cc.Invoke(ctx, "/im.MessageService/GetConversationMessages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) ListConversations(ctx context.Context, in *ListConversationsRequest, opts ...grpc.CallOption) (*ListConversationsResponse, error) {
+	out := new(ListConversationsResponse)
+	err := c.cc.Invoke(ctx, "/im.MessageService/ListConversations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) UpdateConversation(ctx context.Context, in *UpdateConversationRequest, opts ...grpc.CallOption) (*UpdateConversationResponse, error) {
+	out := new(UpdateConversationResponse)
+	err := c.cc.Invoke(ctx, "/im.MessageService/UpdateConversation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) RecallMessage(ctx context.Context, in *RecallMessageRequest, opts ...grpc.CallOption) (*MessageChangeResponse, error) {
+	out := new(MessageChangeResponse)
+	err := c.cc.Invoke(ctx, "/im.MessageService/RecallMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) EditMessage(ctx context.Context, in *EditMessageRequest, opts ...grpc.CallOption) (*MessageChangeResponse, error) {
+	out := new(MessageChangeResponse)
+	err := c.cc.Invoke(ctx, "/im.MessageService/EditMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) SearchMessages(ctx context.Context, in *SearchMessagesRequest, opts ...grpc.CallOption) (*SearchMessagesResponse, error) {
+	out := new(SearchMessagesResponse)
+	err := c.cc.Invoke(ctx, "/im.MessageService/SearchMessages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MessageServiceServer is the server API for MessageService service.
 // All implementations must embed UnimplementedMessageServiceServer
 // for forward compatibility
 type MessageServiceServer interface {
 	// 存储消息
 	StoreMessage(context.Context, *StoreMessageRequest) (*StoreMessageResponse, error)
+	// 按 client_msg_id 查找已存储的消息，用于重发时跳过耗时的预处理；不存在时返回 NotFound
+	FindMessageByClientMsgID(context.Context, *FindMessageByClientMsgIDRequest) (*StoreMessageResponse, error)
 	// 获取未读消息（仅用于单聊）
 	GetUnreadMessages(context.Context, *GetUnreadMessagesRequest) (*GetUnreadMessagesResponse, error)
 	// 获取群聊消息（分页）
 	GetGroupMessages(context.Context, *GetGroupMessagesRequest) (*GetGroupMessagesResponse, error)
+	// 标记消息已读（仅用于单聊）
+	MarkRead(context.Context, *MarkReadRequest) (*MarkReadResponse, error)
+	// 确认消息已送达客户端（仅用于单聊）
+	AckMessages(context.Context, *AckMessagesRequest) (*AckMessagesResponse, error)
+	// 按会话序列号区间获取消息，用于客户端补齐缺口
+	GetMessagesBySeq(context.Context, *GetMessagesBySeqRequest) (*GetMessagesBySeqResponse, error)
+	// 获取两个用户之间的私聊历史（按消息ID游标分页）
+	GetConversationMessages(context.Context, *GetConversationMessagesRequest) (*GetConversationMessagesResponse, error)
+	// 获取用户的会话列表
+	ListConversations(context.Context, *ListConversationsRequest) (*ListConversationsResponse, error)
+	// 更新会话的置顶/隐藏/归档标记，或清零未读数
+	UpdateConversation(context.Context, *UpdateConversationRequest) (*UpdateConversationResponse, error)
+	// 撤回消息（仅发送者，且在撤回时限内）
+	RecallMessage(context.Context, *RecallMessageRequest) (*MessageChangeResponse, error)
+	// 编辑消息（仅发送者），编辑前的内容保存为编辑历史
+	EditMessage(context.Context, *EditMessageRequest) (*MessageChangeResponse, error)
+	// 在用户参与的会话中全文检索文本消息，按时间倒序（按消息ID游标分页）
+	SearchMessages(context.Context, *SearchMessagesRequest) (*SearchMessagesResponse, error)
 	mustEmbedUnimplementedMessageServiceServer()
 }
 
@@ -85,12 +215,42 @@ type UnimplementedMessageServiceServer struct {
 func (UnimplementedMessageServiceServer) StoreMessage(context.Context, *StoreMessageRequest) (*StoreMessageResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StoreMessage not implemented")
 }
+func (UnimplementedMessageServiceServer) FindMessageByClientMsgID(context.Context, *FindMessageByClientMsgIDRequest) (*StoreMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindMessageByClientMsgID not implemented")
+}
 func (UnimplementedMessageServiceServer) GetUnreadMessages(context.Context, *GetUnreadMessagesRequest) (*GetUnreadMessagesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUnreadMessages not implemented")
 }
 func (UnimplementedMessageServiceServer) GetGroupMessages(context.Context, *GetGroupMessagesRequest) (*GetGroupMessagesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetGroupMessages not implemented")
 }
+func (UnimplementedMessageServiceServer) MarkRead(context.Context, *MarkReadRequest) (*MarkReadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkRead not implemented")
+}
+func (UnimplementedMessageServiceServer) AckMessages(context.Context, *AckMessagesRequest) (*AckMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AckMessages not implemented")
+}
+func (UnimplementedMessageServiceServer) GetMessagesBySeq(context.Context, *GetMessagesBySeqRequest) (*GetMessagesBySeqResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMessagesBySeq not implemented")
+}
+func (UnimplementedMessageServiceServer) GetConversationMessages(context.Context, *GetConversationMessagesRequest) (*GetConversationMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConversationMessages not implemented")
+}
+func (UnimplementedMessageServiceServer) ListConversations(context.Context, *ListConversationsRequest) (*ListConversationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListConversations not implemented")
+}
+func (UnimplementedMessageServiceServer) UpdateConversation(context.Context, *UpdateConversationRequest) (*UpdateConversationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateConversation not implemented")
+}
+func (UnimplementedMessageServiceServer) RecallMessage(context.Context, *RecallMessageRequest) (*MessageChangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecallMessage not implemented")
+}
+func (UnimplementedMessageServiceServer) EditMessage(context.Context, *EditMessageRequest) (*MessageChangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EditMessage not implemented")
+}
+func (UnimplementedMessageServiceServer) SearchMessages(context.Context, *SearchMessagesRequest) (*SearchMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchMessages not implemented")
+}
 func (UnimplementedMessageServiceServer) mustEmbedUnimplementedMessageServiceServer() {}
 
 // UnsafeMessageServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -122,6 +282,24 @@ func _MessageService_StoreMessage_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MessageService_FindMessageByClientMsgID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindMessageByClientMsgIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).FindMessageByClientMsgID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.MessageService/FindMessageByClientMsgID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).FindMessageByClientMsgID(ctx, req.(*FindMessageByClientMsgIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MessageService_GetUnreadMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetUnreadMessagesRequest)
 	if err := dec(in); err != nil {
@@ -158,6 +336,168 @@ func _MessageService_GetGroupMessages_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MessageService_MarkRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).MarkRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.MessageService/MarkRead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).MarkRead(ctx, req.(*MarkReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_AckMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AckMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).AckMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.MessageService/AckMessages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).AckMessages(ctx, req.(*AckMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_GetMessagesBySeq_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMessagesBySeqRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).GetMessagesBySeq(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.MessageService/GetMessagesBySeq",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).GetMessagesBySeq(ctx, req.(*GetMessagesBySeqRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_GetConversationMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConversationMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).GetConversationMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.MessageService/GetConversationMessages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).GetConversationMessages(ctx, req.(*GetConversationMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_ListConversations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListConversationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).ListConversations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.MessageService/ListConversations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).ListConversations(ctx, req.(*ListConversationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_UpdateConversation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateConversationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).UpdateConversation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.MessageService/UpdateConversation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).UpdateConversation(ctx, req.(*UpdateConversationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_RecallMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecallMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).RecallMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.MessageService/RecallMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).RecallMessage(ctx, req.(*RecallMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_EditMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EditMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).EditMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.MessageService/EditMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).EditMessage(ctx, req.(*EditMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_SearchMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).SearchMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.MessageService/SearchMessages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).SearchMessages(ctx, req.(*SearchMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MessageService_ServiceDesc is the grpc.ServiceDesc for MessageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -169,6 +509,10 @@ var MessageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "StoreMessage",
 			Handler:    _MessageService_StoreMessage_Handler,
 		},
+		{
+			MethodName: "FindMessageByClientMsgID",
+			Handler:    _MessageService_FindMessageByClientMsgID_Handler,
+		},
 		{
 			MethodName: "GetUnreadMessages",
 			Handler:    _MessageService_GetUnreadMessages_Handler,
@@ -177,6 +521,42 @@ var MessageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetGroupMessages",
 			Handler:    _MessageService_GetGroupMessages_Handler,
 		},
+		{
+			MethodName: "MarkRead",
+			Handler:    _MessageService_MarkRead_Handler,
+		},
+		{
+			MethodName: "AckMessages",
+			Handler:    _MessageService_AckMessages_Handler,
+		},
+		{
+			MethodName: "GetMessagesBySeq",
+			Handler:    _MessageService_GetMessagesBySeq_Handler,
+		},
+		{
+			MethodName: "GetConversationMessages",
+			Handler:    _MessageService_GetConversationMessages_Handler,
+		},
+		{
+			MethodName: "ListConversations",
+			Handler:    _MessageService_ListConversations_Handler,
+		},
+		{
+			MethodName: "UpdateConversation",
+			Handler:    _MessageService_UpdateConversation_Handler,
+		},
+		{
+			MethodName: "RecallMessage",
+			Handler:    _MessageService_RecallMessage_Handler,
+		},
+		{
+			MethodName: "EditMessage",
+			Handler:    _MessageService_EditMessage_Handler,
+		},
+		{
+			MethodName: "SearchMessages",
+			Handler:    _MessageService_SearchMessages_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "message.proto",
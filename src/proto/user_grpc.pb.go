@@ -29,6 +29,15 @@ type UserServiceClient interface {
 	DeleteUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
 	GetFriends(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*Friends, error)
 	AddFriend(ctx context.Context, in *Contact, opts ...grpc.CallOption) (*AddResponse, error)
+	UpdatePresence(ctx context.Context, in *PresenceRequest, opts ...grpc.CallOption) (*PresenceResponse, error)
+	GetFriendRequests(ctx context.Context, in *FriendRequestsQuery, opts ...grpc.CallOption) (*FriendRequests, error)
+	RespondFriendRequest(ctx context.Context, in *FriendReply, opts ...grpc.CallOption) (*AddResponse, error)
+	RemoveFriend(ctx context.Context, in *Contact, opts ...grpc.CallOption) (*DeleteResponse, error)
+	BlockUser(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*AddResponse, error)
+	UnblockUser(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	GetBlockedUsers(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*Friends, error)
+	SetPrivacy(ctx context.Context, in *PrivacyRequest, opts ...grpc.CallOption) (*AddResponse, error)
+	CheckSendPermission(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*SendPermission, error)
 }
 
 type userServiceClient struct {
@@ -102,6 +111,87 @@ func (c *userServiceClient) AddFriend(ctx context.Context, in *Contact, opts ...
 	return out, nil
 }
 
+func (c *userServiceClient) UpdatePresence(ctx context.Context, in *PresenceRequest, opts ...grpc.CallOption) (*PresenceResponse, error) {
+	out := new(PresenceResponse)
+	err := c.cc.Invoke(ctx, "/im.UserService/UpdatePresence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetFriendRequests(ctx context.Context, in *FriendRequestsQuery, opts ...grpc.CallOption) (*FriendRequests, error) {
+	out := new(FriendRequests)
+	err := c.cc.Invoke(ctx, "/im.UserService/GetFriendRequests", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RespondFriendRequest(ctx context.Context, in *FriendReply, opts ...grpc.CallOption) (*AddResponse, error) {
+	out := new(AddResponse)
+	err := c.cc.Invoke(ctx, "/im.UserService/RespondFriendRequest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RemoveFriend(ctx context.Context, in *Contact, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, "/im.UserService/RemoveFriend", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) BlockUser(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*AddResponse, error) {
+	out := new(AddResponse)
+	err := c.cc.Invoke(ctx, "/im.UserService/BlockUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) UnblockUser(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, "/im.UserService/UnblockUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetBlockedUsers(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*Friends, error) {
+	out := new(Friends)
+	err := c.cc.Invoke(ctx, "/im.UserService/GetBlockedUsers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) SetPrivacy(ctx context.Context, in *PrivacyRequest, opts ...grpc.CallOption) (*AddResponse, error) {
+	out := new(AddResponse)
+	err := c.cc.Invoke(ctx, "/im.UserService/SetPrivacy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) CheckSendPermission(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*SendPermission, error) {
+	out := new(SendPermission)
+	err := c.cc.Invoke(ctx, "/im.UserService/CheckSendPermission", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility
@@ -113,6 +203,15 @@ type UserServiceServer interface {
 	DeleteUser(context.Context, *UserRequest) (*DeleteResponse, error)
 	GetFriends(context.Context, *UserRequest) (*Friends, error)
 	AddFriend(context.Context, *Contact) (*AddResponse, error)
+	UpdatePresence(context.Context, *PresenceRequest) (*PresenceResponse, error)
+	GetFriendRequests(context.Context, *FriendRequestsQuery) (*FriendRequests, error)
+	RespondFriendRequest(context.Context, *FriendReply) (*AddResponse, error)
+	RemoveFriend(context.Context, *Contact) (*DeleteResponse, error)
+	BlockUser(context.Context, *BlockRequest) (*AddResponse, error)
+	UnblockUser(context.Context, *BlockRequest) (*DeleteResponse, error)
+	GetBlockedUsers(context.Context, *UserRequest) (*Friends, error)
+	SetPrivacy(context.Context, *PrivacyRequest) (*AddResponse, error)
+	CheckSendPermission(context.Context, *BlockRequest) (*SendPermission, error)
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -141,6 +240,33 @@ func (UnimplementedUserServiceServer) GetFriends(context.Context, *UserRequest)
 func (UnimplementedUserServiceServer) AddFriend(context.Context, *Contact) (*AddResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddFriend not implemented")
 }
+func (UnimplementedUserServiceServer) UpdatePresence(context.Context, *PresenceRequest) (*PresenceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePresence not implemented")
+}
+func (UnimplementedUserServiceServer) GetFriendRequests(context.Context, *FriendRequestsQuery) (*FriendRequests, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFriendRequests not implemented")
+}
+func (UnimplementedUserServiceServer) RespondFriendRequest(context.Context, *FriendReply) (*AddResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RespondFriendRequest not implemented")
+}
+func (UnimplementedUserServiceServer) RemoveFriend(context.Context, *Contact) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveFriend not implemented")
+}
+func (UnimplementedUserServiceServer) BlockUser(context.Context, *BlockRequest) (*AddResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockUser not implemented")
+}
+func (UnimplementedUserServiceServer) UnblockUser(context.Context, *BlockRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnblockUser not implemented")
+}
+func (UnimplementedUserServiceServer) GetBlockedUsers(context.Context, *UserRequest) (*Friends, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockedUsers not implemented")
+}
+func (UnimplementedUserServiceServer) SetPrivacy(context.Context, *PrivacyRequest) (*AddResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPrivacy not implemented")
+}
+func (UnimplementedUserServiceServer) CheckSendPermission(context.Context, *BlockRequest) (*SendPermission, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckSendPermission not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 
 // UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -280,6 +406,168 @@ func _UserService_AddFriend_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_UpdatePresence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PresenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UpdatePresence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.UserService/UpdatePresence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UpdatePresence(ctx, req.(*PresenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetFriendRequests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FriendRequestsQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetFriendRequests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.UserService/GetFriendRequests",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetFriendRequests(ctx, req.(*FriendRequestsQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RespondFriendRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FriendReply)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RespondFriendRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.UserService/RespondFriendRequest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RespondFriendRequest(ctx, req.(*FriendReply))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RemoveFriend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Contact)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RemoveFriend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.UserService/RemoveFriend",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RemoveFriend(ctx, req.(*Contact))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_BlockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).BlockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.UserService/BlockUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).BlockUser(ctx, req.(*BlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_UnblockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UnblockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.UserService/UnblockUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UnblockUser(ctx, req.(*BlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetBlockedUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetBlockedUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.UserService/GetBlockedUsers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetBlockedUsers(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_SetPrivacy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PrivacyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).SetPrivacy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.UserService/SetPrivacy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).SetPrivacy(ctx, req.(*PrivacyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_CheckSendPermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).CheckSendPermission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/im.UserService/CheckSendPermission",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).CheckSendPermission(ctx, req.(*BlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -315,6 +603,42 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddFriend",
 			Handler:    _UserService_AddFriend_Handler,
 		},
+		{
+			MethodName: "UpdatePresence",
+			Handler:    _UserService_UpdatePresence_Handler,
+		},
+		{
+			MethodName: "GetFriendRequests",
+			Handler:    _UserService_GetFriendRequests_Handler,
+		},
+		{
+			MethodName: "RespondFriendRequest",
+			Handler:    _UserService_RespondFriendRequest_Handler,
+		},
+		{
+			MethodName: "RemoveFriend",
+			Handler:    _UserService_RemoveFriend_Handler,
+		},
+		{
+			MethodName: "BlockUser",
+			Handler:    _UserService_BlockUser_Handler,
+		},
+		{
+			MethodName: "UnblockUser",
+			Handler:    _UserService_UnblockUser_Handler,
+		},
+		{
+			MethodName: "GetBlockedUsers",
+			Handler:    _UserService_GetBlockedUsers_Handler,
+		},
+		{
+			MethodName: "SetPrivacy",
+			Handler:    _UserService_SetPrivacy_Handler,
+		},
+		{
+			MethodName: "CheckSendPermission",
+			Handler:    _UserService_CheckSendPermission_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "user.proto",
@@ -31,6 +31,8 @@ func Router(service *service.UserService) *gin.Engine {
 
 	r.POST("/api/register", service.Register)
 	r.POST("/api/login", service.Login)
+	r.POST("/api/refresh", service.Refresh)
+	r.GET("/.well-known/jwks.json", service.JWKS)
 
 	user := r.Group("/api/user")
 	user.Use(utils.JWTAuthMiddlewareForWS())
@@ -38,7 +40,57 @@ func Router(service *service.UserService) *gin.Engine {
 		user.GET("/ws", service.UpgradeWebSocket)
 		user.GET("/friends", service.GetFriends)
 		user.POST("/addfriend", service.AddFriend)
+		user.GET("/friendRequests", service.GetFriendRequests)
+		user.POST("/acceptFriend", service.AcceptFriend)
+		user.POST("/rejectFriend", service.RejectFriend)
+		user.POST("/removeFriend", service.RemoveFriend)
+		user.POST("/block", service.BlockUser)
+		user.POST("/unblock", service.UnblockUser)
+		user.GET("/blocked", service.GetBlockedUsers)
+		user.POST("/privacy", service.SetPrivacy)
 		user.POST("/logout", service.Logout)
+		user.POST("/delete", service.DeleteUser)
+	}
+
+	group := r.Group("/api/group")
+	group.Use(utils.JWTAuthMiddlewareForWS())
+	{
+		group.POST("/create", service.CreateGroup)
+		group.POST("/dissolve", service.DissolveGroup)
+		group.POST("/addMembers", service.AddGroupMembers)
+		group.POST("/removeMembers", service.RemoveGroupMembers)
+		group.GET("/list", service.GetGroups)
+		group.GET("/members", service.GetGroupMembers)
+	}
+
+	message := r.Group("/api/message")
+	message.Use(utils.JWTAuthMiddlewareForWS())
+	{
+		message.GET("/history", service.GetHistory)
+		message.GET("/groupHistory", service.GetGroupHistory)
+		message.GET("/search", service.SearchMessages)
+		message.GET("/conversations", service.GetConversations)
+		message.POST("/conversations/update", service.UpdateConversation)
+		message.POST("/recall", service.RecallMessage)
+		message.POST("/edit", service.EditMessage)
+	}
+
+	// 签名下载链接，不经过登录校验
+	r.GET("/media/:id", service.DownloadSignedMedia)
+
+	media := r.Group("/api/media")
+	media.Use(utils.JWTAuthMiddlewareForWS())
+	{
+		media.POST("/upload", service.UploadMedia)
+		media.GET("/:id", service.DownloadMedia)
+		media.GET("/:id/thumb", service.DownloadThumbnail)
+		media.GET("/:id/url", service.GetMediaURL)
+		// 分片上传，支持断点续传
+		media.POST("/uploads", service.InitUpload)
+		media.GET("/uploads/:uploadId", service.GetUpload)
+		media.PUT("/uploads/:uploadId/chunks/:index", service.PutUploadChunk)
+		media.POST("/uploads/:uploadId/complete", service.CompleteUpload)
+		media.DELETE("/uploads/:uploadId", service.CancelUpload)
 	}
 
 	// 要对api进行升级，后续使用JWTAuthMiddlewareForWS
@@ -0,0 +1,85 @@
+package rpcClient
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/hoyang/imserver/src/proto"
+	"google.golang.org/grpc"
+)
+
+// GroupProxy 群组服务代理
+type GroupProxy struct {
+	client pb.GroupServiceClient
+}
+
+// NewGroupProxy 创建群组服务代理
+func NewGroupProxy(conn *grpc.ClientConn) *GroupProxy {
+	return &GroupProxy{
+		client: pb.NewGroupServiceClient(conn),
+	}
+}
+
+// CreateGroup 创建群组
+func (p *GroupProxy) CreateGroup(ownerID uint64, name string, memberIDs []uint64) (*pb.Group, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return p.client.CreateGroup(ctx, &pb.CreateGroupRequest{
+		Name:      name,
+		OwnerId:   ownerID,
+		MemberIds: memberIDs,
+	})
+}
+
+// DissolveGroup 解散群组
+func (p *GroupProxy) DissolveGroup(operatorID, groupID uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := p.client.DissolveGroup(ctx, &pb.GroupRequest{
+		GroupId:    groupID,
+		OperatorId: operatorID,
+	})
+	return err
+}
+
+// AddMembers 添加群成员
+func (p *GroupProxy) AddMembers(operatorID, groupID uint64, userIDs []uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := p.client.AddMembers(ctx, &pb.GroupMembersRequest{
+		GroupId:    groupID,
+		OperatorId: operatorID,
+		UserIds:    userIDs,
+	})
+	return err
+}
+
+// RemoveMembers 移除群成员
+func (p *GroupProxy) RemoveMembers(operatorID, groupID uint64, userIDs []uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := p.client.RemoveMembers(ctx, &pb.GroupMembersRequest{
+		GroupId:    groupID,
+		OperatorId: operatorID,
+		UserIds:    userIDs,
+	})
+	return err
+}
+
+// GetUserGroups 获取用户加入的群组
+func (p *GroupProxy) GetUserGroups(userID uint64) (*pb.Groups, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return p.client.GetUserGroups(ctx, &pb.ListGroupsRequest{UserId: userID})
+}
+
+// GetGroupMembers 获取群成员ID列表
+func (p *GroupProxy) GetGroupMembers(groupID uint64) ([]uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := p.client.GetGroupMembers(ctx, &pb.GroupRequest{GroupId: groupID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.MemberIds, nil
+}
@@ -0,0 +1,42 @@
+package rpcClient
+
+import (
+	"context"
+
+	pb "github.com/hoyang/imserver/src/proto"
+	"google.golang.org/grpc"
+)
+
+// MediaProxy 媒体服务代理
+type MediaProxy struct {
+	client pb.MediaServiceClient
+}
+
+// NewMediaProxy 创建媒体服务代理
+func NewMediaProxy(conn *grpc.ClientConn) *MediaProxy {
+	return &MediaProxy{
+		client: pb.NewMediaServiceClient(conn),
+	}
+}
+
+// CreateAttachment 登记已写入存储的附件
+func (p *MediaProxy) CreateAttachment(attachment *pb.Attachment) (*pb.Attachment, error) {
+	return p.client.CreateAttachment(context.Background(), attachment)
+}
+
+// GetAttachment 获取附件，userID 无权访问时返回 PermissionDenied
+func (p *MediaProxy) GetAttachment(id, userID uint64) (*pb.Attachment, error) {
+	return p.client.GetAttachment(context.Background(), &pb.AttachmentRequest{
+		Id:     id,
+		UserId: userID,
+	})
+}
+
+// FindAttachmentByHash 按内容哈希查找用户自己上传过的附件，不存在时返回 NotFound
+func (p *MediaProxy) FindAttachmentByHash(userID uint64, sha256 string, size int64) (*pb.Attachment, error) {
+	return p.client.FindAttachmentByHash(context.Background(), &pb.AttachmentHashRequest{
+		UserId: userID,
+		Sha256: sha256,
+		Size:   size,
+	})
+}
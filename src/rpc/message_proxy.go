@@ -2,12 +2,9 @@ package rpcClient
 
 import (
 	"context"
-	"time"
 
-	im "github.com/hoyang/imserver/src/proto"
 	pb "github.com/hoyang/imserver/src/proto"
 	"google.golang.org/grpc"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // MessageProxy 消息服务代理
@@ -22,26 +19,19 @@ func NewMessageProxy(conn *grpc.ClientConn) *MessageProxy {
 	}
 }
 
-// StoreMessage 存储消息
-func (p *MessageProxy) StoreMessage(fromID, toID uint64, msgType im.MessageType, content []byte) (uint64, error) {
-	now := time.Now()
-	msg := &pb.Message{
-		FromId:    fromID,
-		ToId:      toID,
-		Type:      msgType,
-		Content:   content,
-		CreatedAt: timestamppb.New(now),
-		UpdatedAt: timestamppb.New(now),
-	}
-
-	resp, err := p.client.StoreMessage(context.Background(), &pb.StoreMessageRequest{
+// StoreMessage 存储消息，创建时间由dbproxy填写
+func (p *MessageProxy) StoreMessage(msg *pb.Message) (*pb.StoreMessageResponse, error) {
+	return p.client.StoreMessage(context.Background(), &pb.StoreMessageRequest{
 		Message: msg,
 	})
-	if err != nil {
-		return 0, err
-	}
+}
 
-	return resp.MessageId, nil
+// FindMessageByClientMsgID 按客户端消息ID查找已存储的消息，不存在时返回 NotFound
+func (p *MessageProxy) FindMessageByClientMsgID(fromID uint64, clientMsgID string) (*pb.StoreMessageResponse, error) {
+	return p.client.FindMessageByClientMsgID(context.Background(), &pb.FindMessageByClientMsgIDRequest{
+		FromId:      fromID,
+		ClientMsgId: clientMsgID,
+	})
 }
 
 // GetUnreadMessages 获取未读消息
@@ -58,16 +48,131 @@ func (p *MessageProxy) GetUnreadMessages(userID, lastMessageID uint64, limit int
 	return resp.Messages, nil
 }
 
-// GetGroupMessages 获取群聊消息
-func (p *MessageProxy) GetGroupMessages(groupID, lastMessageID uint64, limit int32) ([]*pb.Message, error) {
+// GetGroupMessages 获取群聊消息，返回消息（按ID升序）以及是否还有更早的消息
+func (p *MessageProxy) GetGroupMessages(userID, groupID, lastMessageID uint64, limit int32) ([]*pb.Message, bool, error) {
 	resp, err := p.client.GetGroupMessages(context.Background(), &pb.GetGroupMessagesRequest{
+		UserId:        userID,
 		GroupId:       groupID,
 		LastMessageId: lastMessageID,
 		Limit:         limit,
 	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return resp.Messages, resp.HasMore, nil
+}
+
+// MarkRead 标记消息已读，messageIDs 为空时按会话标记
+func (p *MessageProxy) MarkRead(userID uint64, messageIDs []uint64, peerID, upToMessageID uint64) (*pb.MarkReadResponse, error) {
+	return p.client.MarkRead(context.Background(), &pb.MarkReadRequest{
+		UserId:        userID,
+		MessageIds:    messageIDs,
+		PeerId:        peerID,
+		UpToMessageId: upToMessageID,
+	})
+}
+
+// AckMessages 确认消息已送达
+func (p *MessageProxy) AckMessages(userID uint64, messageIDs []uint64) error {
+	_, err := p.client.AckMessages(context.Background(), &pb.AckMessagesRequest{
+		UserId:     userID,
+		MessageIds: messageIDs,
+	})
+	return err
+}
+
+// GetMessagesBySeq 按会话序列号区间获取消息
+func (p *MessageProxy) GetMessagesBySeq(userID uint64, msgType pb.MessageType, targetID, fromSeq, toSeq uint64, limit int32) ([]*pb.Message, error) {
+	resp, err := p.client.GetMessagesBySeq(context.Background(), &pb.GetMessagesBySeqRequest{
+		UserId:   userID,
+		Type:     msgType,
+		TargetId: targetID,
+		FromSeq:  fromSeq,
+		ToSeq:    toSeq,
+		Limit:    limit,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	return resp.Messages, nil
 }
+
+// GetConversationMessages 获取与对方的私聊历史，返回消息（按ID升序）以及翻页方向上是否还有更多
+func (p *MessageProxy) GetConversationMessages(userID, peerID, beforeID, afterID uint64, limit int32) ([]*pb.Message, bool, error) {
+	resp, err := p.client.GetConversationMessages(context.Background(), &pb.GetConversationMessagesRequest{
+		UserId:   userID,
+		PeerId:   peerID,
+		BeforeId: beforeID,
+		AfterId:  afterID,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return resp.Messages, resp.HasMore, nil
+}
+
+// ListConversations 获取会话列表，archived 为 true 时只返回归档的会话
+func (p *MessageProxy) ListConversations(userID uint64, archived bool, offset, limit int32) ([]*pb.Conversation, error) {
+	resp, err := p.client.ListConversations(context.Background(), &pb.ListConversationsRequest{
+		UserId:   userID,
+		Archived: archived,
+		Offset:   offset,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Conversations, nil
+}
+
+// UpdateConversation 更新会话标记或清零未读数，返回更新后的会话
+func (p *MessageProxy) UpdateConversation(req *pb.UpdateConversationRequest) (*pb.Conversation, error) {
+	resp, err := p.client.UpdateConversation(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Conversation, nil
+}
+
+// RecallMessage 撤回消息，返回撤回后的消息
+func (p *MessageProxy) RecallMessage(userID, messageID uint64) (*pb.Message, error) {
+	resp, err := p.client.RecallMessage(context.Background(), &pb.RecallMessageRequest{
+		UserId:    userID,
+		MessageId: messageID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Message, nil
+}
+
+// EditMessage 编辑消息，返回编辑后的消息
+func (p *MessageProxy) EditMessage(userID, messageID uint64, content []byte) (*pb.Message, error) {
+	resp, err := p.client.EditMessage(context.Background(), &pb.EditMessageRequest{
+		UserId:    userID,
+		MessageId: messageID,
+		Content:   content,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Message, nil
+}
+
+// SearchMessages 在用户参与的会话中检索文本消息，返回命中结果以及是否还有更多
+func (p *MessageProxy) SearchMessages(req *pb.SearchMessagesRequest) ([]*pb.SearchHit, bool, error) {
+	resp, err := p.client.SearchMessages(context.Background(), req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return resp.Hits, resp.HasMore, nil
+}
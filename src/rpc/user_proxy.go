@@ -0,0 +1,125 @@
+package rpcClient
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/hoyang/imserver/src/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UserProxy 用户服务代理
+type UserProxy struct {
+	client pb.UserServiceClient
+}
+
+// NewUserProxy 创建用户服务代理
+func NewUserProxy(conn *grpc.ClientConn) *UserProxy {
+	return &UserProxy{
+		client: pb.NewUserServiceClient(conn),
+	}
+}
+
+// GetFriendIDs 获取用户的好友ID列表
+func (p *UserProxy) GetFriendIDs(userID uint64) ([]uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := p.client.GetFriends(ctx, &pb.UserRequest{Id: userID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, 0, len(resp.Friendlist))
+	for _, f := range resp.Friendlist {
+		ids = append(ids, f.Id)
+	}
+	return ids, nil
+}
+
+// UpdatePresence 记录用户在线状态变更时间
+func (p *UserProxy) UpdatePresence(userID uint64, online bool, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := p.client.UpdatePresence(ctx, &pb.PresenceRequest{
+		UserId:        userID,
+		Online:        online,
+		HeartbeatTime: timestamppb.New(at),
+	})
+	return err
+}
+
+// GetFriendRequests 获取好友请求，outgoing 为 true 时为自己发出的请求
+func (p *UserProxy) GetFriendRequests(userID uint64, outgoing bool) (*pb.FriendRequests, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return p.client.GetFriendRequests(ctx, &pb.FriendRequestsQuery{
+		UserId:   userID,
+		Outgoing: outgoing,
+	})
+}
+
+// RespondFriendRequest 接受或拒绝好友请求
+func (p *UserProxy) RespondFriendRequest(userID, fromID uint64, accept bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := p.client.RespondFriendRequest(ctx, &pb.FriendReply{
+		UserId: userID,
+		FromId: fromID,
+		Accept: accept,
+	})
+	return err
+}
+
+// RemoveFriend 解除好友关系
+func (p *UserProxy) RemoveFriend(userID, friendID uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := p.client.RemoveFriend(ctx, &pb.Contact{UserID: userID, FriendID: friendID})
+	return err
+}
+
+// BlockUser 拉黑用户
+func (p *UserProxy) BlockUser(userID, targetID uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := p.client.BlockUser(ctx, &pb.BlockRequest{UserId: userID, TargetId: targetID})
+	return err
+}
+
+// UnblockUser 解除拉黑
+func (p *UserProxy) UnblockUser(userID, targetID uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := p.client.UnblockUser(ctx, &pb.BlockRequest{UserId: userID, TargetId: targetID})
+	return err
+}
+
+// GetBlockedUsers 获取黑名单
+func (p *UserProxy) GetBlockedUsers(userID uint64) (*pb.Friends, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return p.client.GetBlockedUsers(ctx, &pb.UserRequest{Id: userID})
+}
+
+// SetPrivacy 更新隐私设置
+func (p *UserProxy) SetPrivacy(userID uint64, friendsOnly bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := p.client.SetPrivacy(ctx, &pb.PrivacyRequest{UserId: userID, FriendsOnly: friendsOnly})
+	return err
+}
+
+// CheckSendPermission 校验能否向对方发送私聊消息
+func (p *UserProxy) CheckSendPermission(fromID, toID uint64) (*pb.SendPermission, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return p.client.CheckSendPermission(ctx, &pb.BlockRequest{UserId: fromID, TargetId: toID})
+}
+
+// DeleteUser 注销账号
+func (p *UserProxy) DeleteUser(userID uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_, err := p.client.DeleteUser(ctx, &pb.UserRequest{Id: userID})
+	return err
+}
@@ -1,73 +1,306 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/hoyang/imserver/src/conveter"
 	"github.com/hoyang/imserver/src/models"
+	im "github.com/hoyang/imserver/src/proto"
 	rpcClient "github.com/hoyang/imserver/src/rpc"
 	"github.com/hoyang/imserver/src/utils"
 	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-type Node struct {
-	Conn      *websocket.Conn
-	DataQueue chan models.Message
-	wg        sync.WaitGroup
-}
-
-func CreateNode(c *websocket.Conn) *Node {
-	var node Node
-	queueSize := 10
-	node.DataQueue = make(chan models.Message, queueSize)
-	node.Conn = c
-	return &node
-}
-
 type ChatService struct {
-	clientMap map[uint64]*Node
+	clientMap map[uint64]map[string]*Node // userID -> deviceID -> node
 	rwLocker  sync.RWMutex
 	redisDB   *redis.Client
 	pool      *rpcClient.ClientPool
+	media     *MediaStore // 内联图片转存为附件
+
+	dispatcher *dispatcher
+	subscriber *utils.Subscriber
+	registry   *ConnRegistry
+	presence   *Presence
+	instanceID string
+	policy     devicePolicy
 }
 
-func NewChatService(redisDB *redis.Client, pool *rpcClient.ClientPool) *ChatService {
-	s := &ChatService{redisDB: redisDB, pool: pool}
-	s.clientMap = make(map[uint64]*Node, 10)
+// 未确认群消息的保留时间
+const pendingTTL = 5 * time.Minute
+
+func NewChatService(redisDB *redis.Client, pool *rpcClient.ClientPool, media *MediaStore) *ChatService {
+	s := &ChatService{redisDB: redisDB, pool: pool, media: media}
+	s.clientMap = make(map[uint64]map[string]*Node, 10)
+	s.instanceID = instanceID()
+	s.policy = loadDevicePolicy()
+	s.registry = NewConnRegistry(redisDB, s.instanceID)
+	s.presence = NewPresence(redisDB, s.registry)
+	s.dispatcher = newDispatcher(s.dispatch)
+	// 只订阅本实例的频道，发布方按连接注册表定向投递
+	s.subscriber = utils.NewSubscriber(redisDB, "msg_subscriber", utils.InstanceChannel(s.instanceID))
 	return s
 }
 
+// 从发布到接收超过该时长的消息计为延迟
+const lateThreshold = 2 * time.Second
+
 func (s *ChatService) Subscription() {
-	ctx := context.Background()
+	go s.subscriber.Run(context.Background(), func(payload string) {
+		env, err := models.EnvelopeFromString(payload)
+		if err != nil {
+			log.Printf("解析总线事件失败: %v", err)
+			s.subscriber.MarkDropped()
+			return
+		}
+		if env.SentAt > 0 && time.Since(time.UnixMilli(env.SentAt)) > lateThreshold {
+			s.subscriber.MarkLate()
+		}
+		// 按会话分片串行转发，保证同一会话内的消息顺序
+		// 聊天消息不能丢弃，分片满时阻塞订阅；其余事件（上线通知、回执等）满载时丢弃
+		if env.Action == models.ActionMessage {
+			s.dispatcher.submitWait(env.Key, env)
+			return
+		}
+		if !s.dispatcher.submit(env.Key, env) {
+			log.Printf("分发队列已满，丢弃事件: %s", env.Key)
+			s.subscriber.MarkDropped()
+		}
+	})
+}
+
+// WatchPresence 定期检查心跳过期的用户，标记离线并通知好友
+func (s *ChatService) WatchPresence() {
 	go func() {
-		for {
-			msg, err := utils.Subscription(s.redisDB, ctx, "msgChannel")
+		ticker := time.NewTicker(presenceSweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx := context.Background()
+			offline, err := s.presence.Expired(ctx)
 			if err != nil {
-				log.Printf("recive msg err %v", err)
-				continue
+				log.Printf("检查在线状态失败: %v", err)
+			}
+			for _, userID := range offline {
+				s.notifyPresence(ctx, userID, false)
 			}
-			// 根据targetId转发消息到对应的user node，可能会导致消息顺序错误
-			go func() {
-				log.Println("Subscription revice:", msg)
-				message, _ := models.MessageFromString(msg)
-				log.Println("targetId,", message.ToID)
-				s.rwLocker.RLock()
-				node := s.clientMap[message.ToID]
-				s.rwLocker.RUnlock()
-				if node != nil {
-					node.DataQueue <- message
-				}
-			}()
 		}
 	}()
 }
 
+// touchPresence 连接建立或收到心跳时续期在线状态，由离线变为在线时通知好友
+func (s *ChatService) touchPresence(ctx context.Context, userID uint64) {
+	online, err := s.presence.Touch(ctx, userID)
+	if err != nil {
+		log.Printf("更新在线状态失败, userID: %d, err: %v", userID, err)
+		return
+	}
+	if online {
+		s.notifyPresence(ctx, userID, true)
+	}
+}
+
+// notifyPresence 记录在线状态变更时间，并推送给在线的好友
+func (s *ChatService) notifyPresence(ctx context.Context, userID uint64, online bool) {
+	now := time.Now()
+	conn := s.pool.Get()
+	proxy := rpcClient.NewUserProxy(conn)
+	if err := proxy.UpdatePresence(userID, online, now); err != nil {
+		log.Printf("UpdatePresence failed, userID: %d, err: %v", userID, err)
+	}
+	friendIDs, err := proxy.GetFriendIDs(userID)
+	s.pool.Put(conn)
+	if err != nil {
+		log.Printf("获取好友列表失败, userID: %d, err: %v", userID, err)
+		return
+	}
+
+	event := &models.PresenceEvent{Action: models.ActionPresence, UserID: userID, Online: online, LastSeen: now}
+	if err := s.publish(ctx, models.ActionPresence, friendIDs, event, ""); err != nil {
+		log.Printf("发布在线状态失败, userID: %d, err: %v", userID, err)
+	}
+}
+
+// Notify 向用户的所有在线设备推送通知事件
+func (s *ChatService) Notify(ctx context.Context, userID uint64, action string, event any) error {
+	return s.publish(ctx, action, []uint64{userID}, event, "")
+}
+
+// OnlineMap 批量查询用户是否在线
+func (s *ChatService) OnlineMap(ctx context.Context, userIDs []uint64) (map[uint64]bool, error) {
+	return s.presence.OnlineMap(ctx, userIDs)
+}
+
+// dispatch 将总线上的事件转发到本实例对应的user node
+func (s *ChatService) dispatch(env models.Envelope) {
+	switch env.Action {
+	case models.ActionMessage:
+		var message models.Message
+		if err := json.Unmarshal(env.Data, &message); err != nil {
+			log.Printf("解析消息失败: %v", err)
+			return
+		}
+		for _, id := range env.Receivers {
+			// 发送者的其他设备同步这条消息，发出消息的设备除外
+			skip := ""
+			if id == message.FromID {
+				skip = env.Origin
+			}
+			s.deliver(id, message, skip)
+		}
+	case models.ActionKicked:
+		var kicked models.Kicked
+		if err := json.Unmarshal(env.Data, &kicked); err != nil {
+			log.Printf("解析踢线事件失败: %v", err)
+			return
+		}
+		for _, id := range env.Receivers {
+			s.kickLocal(id, &kicked)
+		}
+	default:
+		// 其他事件原样推送给接收者
+		for _, id := range env.Receivers {
+			s.deliver(id, env.Data, "")
+		}
+	}
+}
+
+// userNodes 返回用户在本实例上的全部连接
+func (s *ChatService) userNodes(userID uint64) []*Node {
+	s.rwLocker.RLock()
+	defer s.rwLocker.RUnlock()
+	nodes := make([]*Node, 0, len(s.clientMap[userID]))
+	for _, node := range s.clientMap[userID] {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// deliver 将帧推送给本实例上用户的所有设备，skipDevice 指定的设备除外
+func (s *ChatService) deliver(userID uint64, frame any, skipDevice string) {
+	for _, node := range s.userNodes(userID) {
+		if node.DeviceID == skipDevice {
+			continue
+		}
+		node.enqueue(frame)
+	}
+}
+
+// kickLocal 踢下本实例上用户的指定设备，未指定设备时踢下全部设备
+// 指定了令牌时只踢下使用该令牌建立的连接
+func (s *ChatService) kickLocal(userID uint64, kicked *models.Kicked) {
+	if kicked.DeviceID == "" {
+		for _, node := range s.userNodes(userID) {
+			if kicked.TokenID != "" && node.TokenID != kicked.TokenID {
+				continue
+			}
+			log.Printf("踢下线, userID: %d, device: %s, reason: %s", userID, node.DeviceID, kicked.Reason)
+			node.kick(&models.Kicked{Action: kicked.Action, DeviceID: node.DeviceID, Reason: kicked.Reason})
+		}
+		return
+	}
+	s.rwLocker.RLock()
+	node := s.clientMap[userID][kicked.DeviceID]
+	s.rwLocker.RUnlock()
+	if node != nil {
+		log.Printf("踢下线, userID: %d, device: %s, reason: %s", userID, kicked.DeviceID, kicked.Reason)
+		node.kick(kicked)
+	}
+}
+
+// Disconnect 断开用户在所有实例上的全部连接
+func (s *ChatService) Disconnect(ctx context.Context, userID uint64, reason string) error {
+	kicked := &models.Kicked{Action: models.ActionKicked, Reason: reason}
+	return s.publish(ctx, models.ActionKicked, []uint64{userID}, kicked, "")
+}
+
+// DisconnectToken 断开用户在所有实例上使用指定令牌建立的连接
+func (s *ChatService) DisconnectToken(ctx context.Context, userID uint64, tokenID, reason string) error {
+	kicked := &models.Kicked{Action: models.ActionKicked, Reason: reason, TokenID: tokenID}
+	return s.publish(ctx, models.ActionKicked, []uint64{userID}, kicked, "")
+}
+
+// BroadcastChange 将消息的撤回或编辑推送给会话的全部参与者（含发送者的其他设备）
+// 离线的参与者上线后拉取历史时得到的已是修改后的消息
+func (s *ChatService) BroadcastChange(ctx context.Context, action string, msg *models.Message) error {
+	receivers := []uint64{msg.FromID}
+	switch msg.Type {
+	case models.MessageTypePrivate:
+		receivers = append(receivers, msg.ToID)
+	case models.MessageTypeGroup:
+		members, err := s.getGroupMembers(msg.ToID)
+		if err != nil {
+			return err
+		}
+		receivers = members
+	}
+
+	change := &models.MessageChange{
+		Action:    action,
+		MessageID: msg.ID,
+		FromID:    msg.FromID,
+		TargetID:  msg.ToID,
+		Type:      msg.Type,
+		Seq:       msg.Seq,
+		Content:   msg.Content,
+		ChangedAt: msg.UpdatedAt,
+	}
+	return s.publish(ctx, action, receivers, change, "")
+}
+
+// publish 按连接注册表将事件定向发布到接收者所在实例的频道
+// 接收者都不在线时不发布：消息已落库，上线后作为离线消息补发
+// origin 为发出消息的设备ID，该设备不会再收到这条消息
+func (s *ChatService) publish(ctx context.Context, action string, receivers []uint64, data any, origin string) error {
+	if len(receivers) == 0 {
+		return nil
+	}
+	instances, err := s.registry.Lookup(ctx, receivers)
+	if err != nil {
+		return err
+	}
+
+	// 消息按会话排序，其他事件按接收者排序
+	key := fmt.Sprintf("u:%d", receivers[0])
+	switch msg := data.(type) {
+	case *models.Message:
+		key = models.ConversationKey(msg.Type, msg.FromID, msg.ToID)
+	case *models.MessageChange:
+		// 撤回和编辑与原消息所在会话的消息保持顺序
+		key = models.ConversationKey(msg.Type, msg.FromID, msg.TargetID)
+	}
+	for instance, userIDs := range instances {
+		env, err := models.NewEnvelope(action, userIDs, data)
+		if err != nil {
+			return err
+		}
+		env.Key = key
+		env.Origin = origin
+		if err := utils.Publish(s.redisDB, ctx, utils.InstanceChannel(instance), env.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ChatService) getGroupMembers(groupID uint64) ([]uint64, error) {
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	return rpcClient.NewGroupProxy(conn).GetGroupMembers(groupID)
+}
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -96,10 +329,18 @@ func (s *ChatService) Chat(c *gin.Context) {
 		})
 		return
 	}
-	s.rwLocker.Lock()
-	s.clientMap[userId.(uint64)] = node
-	s.rwLocker.Unlock()
-	defer delete(s.clientMap, userId.(uint64))
+	node.UserID = userId.(uint64)
+	node.DeviceID, node.DeviceClass = deviceFromRequest(c, s.instanceID)
+	if claims, ok := c.Get("claims"); ok {
+		node.TokenID = claims.(*utils.Claims).ID
+	}
+	s.addNode(node)
+	defer s.removeNode(node)
+	if err := s.registry.Register(c, node.UserID, node.DeviceClass); err != nil {
+		log.Printf("登记连接失败, userID: %v, err: %v", userId, err)
+	}
+	s.touchPresence(c, node.UserID)
+	s.enforceDevicePolicy(c, node)
 
 	log.Println("升级websocke成功")
 	response := map[string]interface{}{
@@ -113,11 +354,172 @@ func (s *ChatService) Chat(c *gin.Context) {
 		log.Println("发送消息失败:", err)
 		return
 	}
+	// 读写协程启动后再补发离线消息和未确认消息：补发同样受未确认窗口限制，
+	// 随客户端的ack推进，期间实时消息照常推送
 	s.handlerWebsocket(node, c)
+	node.wg.Add(1)
+	go s.feedBacklog(c, node)
 
 	node.wg.Wait()
+	s.stashPending(node, node.unacked())
 	log.Println("handlerWebsocket msg eixt, userID:", userId)
-	// TODO: 更新user status to offline
+}
+
+// addNode 登记用户在本实例上的连接，同一设备的旧连接被踢下线
+func (s *ChatService) addNode(node *Node) {
+	s.rwLocker.Lock()
+	nodes := s.clientMap[node.UserID]
+	if nodes == nil {
+		nodes = make(map[string]*Node)
+		s.clientMap[node.UserID] = nodes
+	}
+	old := nodes[node.DeviceID]
+	nodes[node.DeviceID] = node
+	s.rwLocker.Unlock()
+
+	if old != nil {
+		old.kick(&models.Kicked{Action: models.ActionKicked, DeviceID: old.DeviceID, Reason: "该设备已重新登录"})
+	}
+}
+
+// removeNode 移除用户的连接，已被同设备的新连接替换时保留新连接
+// 用户在本实例上已无连接时注销连接登记，所有实例上都无连接时标记离线
+func (s *ChatService) removeNode(node *Node) {
+	s.rwLocker.Lock()
+	nodes := s.clientMap[node.UserID]
+	if nodes[node.DeviceID] != node {
+		s.rwLocker.Unlock()
+		return
+	}
+	delete(nodes, node.DeviceID)
+	empty := len(nodes) == 0
+	if empty {
+		delete(s.clientMap, node.UserID)
+	}
+	s.rwLocker.Unlock()
+
+	ctx := context.Background()
+	if err := s.registry.RemoveSession(ctx, node.UserID, node.DeviceClass, node.DeviceID); err != nil {
+		log.Printf("移除设备会话失败, userID: %d, err: %v", node.UserID, err)
+	}
+	if empty {
+		if err := s.registry.Unregister(ctx, node.UserID); err != nil {
+			log.Printf("注销连接失败, userID: %d, err: %v", node.UserID, err)
+		}
+		offline, err := s.presence.Leave(ctx, node.UserID)
+		if err != nil {
+			log.Printf("更新在线状态失败, userID: %d, err: %v", node.UserID, err)
+		} else if offline {
+			s.notifyPresence(ctx, node.UserID, false)
+		}
+	}
+}
+
+// enforceDevicePolicy 按设备策略踢下同类设备上较早登录的会话（可能位于其他实例）
+func (s *ChatService) enforceDevicePolicy(ctx context.Context, node *Node) {
+	limit := s.policy.limit(node.DeviceClass)
+	if limit <= 0 {
+		return
+	}
+	evicted, err := s.registry.AddSession(ctx, node.UserID, node.DeviceClass, node.DeviceID, limit)
+	if err != nil {
+		log.Printf("记录设备会话失败, userID: %d, err: %v", node.UserID, err)
+		return
+	}
+	for _, deviceID := range evicted {
+		kicked := &models.Kicked{Action: models.ActionKicked, DeviceID: deviceID, Reason: "账号已在其他同类设备登录"}
+		if err := s.publish(ctx, models.ActionKicked, []uint64{node.UserID}, kicked, ""); err != nil {
+			log.Printf("发布踢线事件失败, userID: %d, err: %v", node.UserID, err)
+		}
+	}
+}
+
+// 每次拉取离线消息的数量
+const offlinePageSize = 50
+
+// feedBacklog 依次补发离线消息和上次断线时未确认的群消息，补发失败时断开连接等待重连
+func (s *ChatService) feedBacklog(ctx context.Context, node *Node) {
+	defer node.wg.Done()
+	defer close(node.BacklogQueue)
+	if err := s.deliverOfflineMessages(node); err != nil {
+		log.Printf("补发离线消息失败, userID: %d, err: %v", node.UserID, err)
+		node.close()
+		return
+	}
+	if err := s.replayPending(ctx, node); err != nil {
+		log.Printf("重放未确认消息失败, userID: %d, err: %v", node.UserID, err)
+		node.close()
+	}
+}
+
+// deliverOfflineMessages 分页拉取用户的未读消息交给写协程推送
+func (s *ChatService) deliverOfflineMessages(node *Node) error {
+	var lastMessageID uint64
+	for {
+		conn := s.pool.Get()
+		messages, err := rpcClient.NewMessageProxy(conn).GetUnreadMessages(node.UserID, lastMessageID, offlinePageSize)
+		s.pool.Put(conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range messages {
+			msg := conveter.ProtoToMessage(m)
+			if !node.pushBacklog(msg) {
+				return nil
+			}
+			lastMessageID = msg.ID
+		}
+		if len(messages) < offlinePageSize {
+			return nil
+		}
+	}
+}
+
+// replayPending 重放该设备上次断线时未确认的群消息
+// 未确认消息暂存在redis，设备重连到任一实例都能取回（单聊消息由离线消息补发）
+func (s *ChatService) replayPending(ctx context.Context, node *Node) error {
+	key := utils.PendingMessagesKey(node.UserID, node.DeviceID)
+	pipe := s.redisDB.TxPipeline()
+	get := pipe.Get(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if err == redis.Nil {
+			return nil
+		}
+		return err
+	}
+	var msgs []models.Message
+	if err := json.Unmarshal([]byte(get.Val()), &msgs); err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		if !node.pushBacklog(msg) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// stashPending 暂存断线时未确认的群消息，保留 pendingTTL
+func (s *ChatService) stashPending(node *Node, unacked []models.Message) {
+	var msgs []models.Message
+	for _, msg := range unacked {
+		if msg.Type == models.MessageTypeGroup {
+			msgs = append(msgs, msg)
+		}
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	data, err := json.Marshal(msgs)
+	if err != nil {
+		log.Printf("序列化未确认消息失败, userID: %d, err: %v", node.UserID, err)
+		return
+	}
+	key := utils.PendingMessagesKey(node.UserID, node.DeviceID)
+	if err := s.redisDB.Set(context.Background(), key, data, pendingTTL).Err(); err != nil {
+		log.Printf("暂存未确认消息失败, userID: %d, err: %v", node.UserID, err)
+	}
 }
 
 // 设置心跳参数
@@ -127,35 +529,88 @@ const (
 )
 
 func (s *ChatService) handlerWebsocket(node *Node, c *gin.Context) {
-	closeNotify := make(chan any)
-	var closeOnce sync.Once
-	closeFunc := func() {
-		closeOnce.Do(func() {
-			close(closeNotify)
-		})
-	}
+	closeNotify := node.closeNotify
+	closeFunc := node.close
 	//订阅redis消息
 	node.wg.Add(1)
 	go func() {
 		defer node.wg.Done()
+		retransmitTicker := time.NewTicker(time.Second)
+		defer retransmitTicker.Stop()
+		backlog := node.BacklogQueue
 		for {
+			// 未确认窗口已满时暂停推送新消息和补发消息
+			queue, pending := node.DataQueue, backlog
+			if node.windowFull() {
+				queue, pending = nil, nil
+			}
 			select {
 			case <-closeNotify:
 				return
-			case msg := <-node.DataQueue:
-				content, err := json.Marshal(&msg)
-				if err != nil {
-					log.Println("解析失败", err)
+			case msg, ok := <-pending:
+				if !ok {
+					backlog = nil
+					node.backlogDone = true
+					continue
 				}
-				err = node.Conn.WriteMessage(websocket.TextMessage, content)
-				if err != nil {
+				if node.duplicate(msg) {
+					continue
+				}
+				if err := node.write(msg); err != nil {
+					log.Printf("WriteMessage err %v", err)
+					closeFunc()
+					return
+				}
+			case frame := <-queue:
+				if node.duplicate(frame) {
+					continue
+				}
+				if err := node.write(frame); err != nil {
 					log.Printf("WriteMessage err %v", err)
 					closeFunc()
 					return
 				}
+			case frame := <-node.ControlQueue:
+				if err := node.write(frame); err != nil {
+					log.Printf("WriteMessage err %v", err)
+					closeFunc()
+					return
+				}
+				// 下线通知发出后关闭连接
+				if _, ok := frame.(*models.Kicked); ok {
+					closeFunc()
+					return
+				}
+			case <-node.ackNotify:
+			case <-retransmitTicker.C:
+				if err := node.retransmit(); err != nil {
+					log.Printf("retransmit err %v", err)
+					closeFunc()
+					return
+				}
 			}
 		}
 	}()
+
+	// 收到pong说明连接存活，续期连接登记和在线状态；超过 pongWait 未收到则读超时断开
+	node.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	node.Conn.SetPongHandler(func(string) error {
+		node.Conn.SetReadDeadline(time.Now().Add(pongWait))
+		ctx := context.Background()
+		if err := s.registry.Register(ctx, node.UserID, node.DeviceClass); err != nil {
+			log.Printf("续期连接登记失败, userID: %d, err: %v", node.UserID, err)
+		}
+		s.touchPresence(ctx, node.UserID)
+		return nil
+	})
+
+	// 回复给本连接的控制帧，连接关闭后丢弃
+	reply := func(frame any) {
+		select {
+		case node.ControlQueue <- frame:
+		case <-closeNotify:
+		}
+	}
 	node.wg.Add(1)
 	//将客户端消息publish到redis
 	go func() {
@@ -174,17 +629,44 @@ func (s *ChatService) handlerWebsocket(node *Node, c *gin.Context) {
 				}
 				log.Println("receive message:", string(message))
 
-				var msg models.Message
-				err = json.Unmarshal(message, &msg)
-				if err != nil {
+				var head struct {
+					Action string `json:"action"`
+				}
+				if err := json.Unmarshal(message, &head); err != nil {
 					log.Println("解析错误:", err)
-					return
+					reply(models.NewSendFailure("", models.SendErrInvalid, "无法解析的消息"))
+					continue
+				}
+				switch head.Action {
+				case models.ActionAck:
+					var event models.AckEvent
+					if err := json.Unmarshal(message, &event); err != nil {
+						log.Println("解析错误:", err)
+						continue
+					}
+					s.handleAck(node, c.GetUint64("user_id"), &event)
+				case models.ActionRead:
+					var event models.ReadEvent
+					if err := json.Unmarshal(message, &event); err != nil {
+						log.Println("解析错误:", err)
+						continue
+					}
+					s.handleRead(c, c.GetUint64("user_id"), &event)
+				case models.ActionSync:
+					var event models.SyncEvent
+					if err := json.Unmarshal(message, &event); err != nil {
+						log.Println("解析错误:", err)
+						continue
+					}
+					for _, frame := range s.handleSync(c.GetUint64("user_id"), &event) {
+						reply(frame)
+					}
+				case "", models.ActionMessage:
+					// 无 action 字段的帧按聊天消息处理，兼容旧客户端
+					reply(s.handleSend(c, node, message))
+				default:
+					log.Printf("未知的事件类型: %s", head.Action)
 				}
-
-				utils.Publish(s.redisDB, c, "msgChannel", msg.String())
-				conn := s.pool.Get()
-				defer s.pool.Put(conn)
-				rpcClient.NewMessageProxy(conn).StoreMessage(msg.FromID, msg.ToID, msg.Type, msg.Content)
 			}
 		}
 	}()
@@ -210,3 +692,202 @@ func (s *ChatService) handlerWebsocket(node *Node, c *gin.Context) {
 		}
 	}()
 }
+
+// handleSend 处理客户端发送的消息：校验、存储、发布，返回给发送者的结果帧
+// 消息同时同步给发送者的其他设备
+func (s *ChatService) handleSend(ctx context.Context, node *Node, raw []byte) any {
+	var msg models.Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		log.Println("解析错误:", err)
+		return models.NewSendFailure("", models.SendErrInvalid, "无法解析的消息")
+	}
+
+	// 发送者以认证身份为准，防止伪造FromID；消息ID和时间由服务端分配
+	msg.FromID = node.UserID
+	msg.ID = 0
+	msg.CreatedAt, msg.UpdatedAt = time.Time{}, time.Time{}
+
+	if msg.ToID == 0 || (msg.Type != models.MessageTypePrivate && msg.Type != models.MessageTypeGroup) {
+		return models.NewSendFailure(msg.ClientMsgID, models.SendErrInvalid, "接收者或消息类型无效")
+	}
+	if len(msg.Content) == 0 && msg.AttachmentID == 0 {
+		return models.NewSendFailure(msg.ClientMsgID, models.SendErrInvalid, "消息内容不能为空")
+	}
+
+	// 私聊消息需校验是否被对方拉黑，以及对方是否只接收好友消息
+	if msg.Type == models.MessageTypePrivate && msg.ToID != msg.FromID {
+		conn := s.pool.Get()
+		perm, err := rpcClient.NewUserProxy(conn).CheckSendPermission(msg.FromID, msg.ToID)
+		s.pool.Put(conn)
+		if err != nil {
+			log.Printf("CheckSendPermission failed, userId: %d, err: %v", msg.FromID, err)
+			if status.Code(err) == codes.NotFound {
+				return models.NewSendFailure(msg.ClientMsgID, models.SendErrInvalid, "接收者不存在")
+			}
+			return models.NewSendFailure(msg.ClientMsgID, models.SendErrUnavailable, "服务暂不可用，请稍后重试")
+		}
+		if !perm.Allowed {
+			return models.NewSendFailure(msg.ClientMsgID, models.SendErrForbidden, perm.Reason)
+		}
+	}
+
+	// 群消息需校验发送者是否为群成员，接收者为全体成员（含发送者的其他设备）
+	receivers := []uint64{msg.ToID}
+	if msg.Type == models.MessageTypePrivate && msg.ToID != msg.FromID {
+		receivers = append(receivers, msg.FromID)
+	}
+	if msg.Type == models.MessageTypeGroup {
+		memberIDs, err := s.getGroupMembers(msg.ToID)
+		if err != nil {
+			log.Printf("获取群成员失败, groupId: %d, err: %v", msg.ToID, err)
+			return models.NewSendFailure(msg.ClientMsgID, models.SendErrUnavailable, "服务暂不可用，请稍后重试")
+		}
+		if !slices.Contains(memberIDs, msg.FromID) {
+			log.Printf("非群成员发送群消息, userId: %d, groupId: %d", msg.FromID, msg.ToID)
+			return models.NewSendFailure(msg.ClientMsgID, models.SendErrForbidden, "不是该群成员")
+		}
+		receivers = memberIDs
+	}
+
+	// 内联的图片内容转存为附件，消息只引用附件，接收者先拿到尺寸和缩略图
+	// 重发的消息已存储过，直接确认，避免重复转存产生无人引用的附件
+	if msg.ContentType == im.ContentType_PICUTRE && msg.AttachmentID == 0 && len(msg.Content) > 0 {
+		if ack := s.findSent(&msg); ack != nil {
+			return ack
+		}
+		if failure := s.ingestInlineImage(ctx, &msg); failure != nil {
+			return failure
+		}
+	}
+
+	// 先存储获取消息ID和服务端时间，再发布，保证投递的消息一定已落库
+	conn := s.pool.Get()
+	resp, err := rpcClient.NewMessageProxy(conn).StoreMessage(conveter.MessageToProto(&msg))
+	s.pool.Put(conn)
+	if err != nil {
+		log.Printf("StoreMessage failed %v", err)
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			return models.NewSendFailure(msg.ClientMsgID, models.SendErrInvalid, status.Convert(err).Message())
+		case codes.PermissionDenied:
+			return models.NewSendFailure(msg.ClientMsgID, models.SendErrForbidden, status.Convert(err).Message())
+		}
+		return models.NewSendFailure(msg.ClientMsgID, models.SendErrUnavailable, "消息存储失败，请稍后重试")
+	}
+	msg.ID = resp.MessageId
+	msg.Seq = resp.Seq
+	msg.CreatedAt = resp.CreatedAt.AsTime()
+	msg.UpdatedAt = msg.CreatedAt
+	msg.Attachment = conveter.ToDBAttachment(resp.Attachment)
+
+	// 重复发送的消息已投递过，只回复确认
+	if !resp.Duplicate {
+		// 已落库的消息即使发布失败，也会作为离线消息补发给接收者
+		if err := s.publish(ctx, models.ActionMessage, receivers, &msg, node.DeviceID); err != nil {
+			log.Printf("发布消息失败, messageId: %d, err: %v", msg.ID, err)
+		}
+	}
+	return &models.SentAck{
+		Action:      models.ActionSent,
+		ClientMsgID: msg.ClientMsgID,
+		MessageID:   msg.ID,
+		Seq:         msg.Seq,
+		CreatedAt:   msg.CreatedAt,
+		Duplicate:   resp.Duplicate,
+	}
+}
+
+// findSent 查找按 ClientMsgID 已存储的消息，存在时返回重复发送的确认
+// 查询失败时返回 nil，由 StoreMessage 再做一次去重
+func (s *ChatService) findSent(msg *models.Message) *models.SentAck {
+	if msg.ClientMsgID == "" {
+		return nil
+	}
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	resp, err := rpcClient.NewMessageProxy(conn).FindMessageByClientMsgID(msg.FromID, msg.ClientMsgID)
+	if err != nil {
+		if status.Code(err) != codes.NotFound {
+			log.Printf("FindMessageByClientMsgID failed %v", err)
+		}
+		return nil
+	}
+	return &models.SentAck{
+		Action:      models.ActionSent,
+		ClientMsgID: msg.ClientMsgID,
+		MessageID:   resp.MessageId,
+		Seq:         resp.Seq,
+		CreatedAt:   resp.CreatedAt.AsTime(),
+		Duplicate:   true,
+	}
+}
+
+// ingestInlineImage 将消息内容中的图片保存为附件；内容不是图片时保持原样发送
+func (s *ChatService) ingestInlineImage(ctx context.Context, msg *models.Message) *models.SendFailure {
+	if !strings.HasPrefix(http.DetectContentType(msg.Content), "image/") {
+		return nil
+	}
+	if len(msg.Content) > maxUploadSize {
+		return models.NewSendFailure(msg.ClientMsgID, models.SendErrInvalid, "图片过大")
+	}
+	attachment, err := s.media.Save(ctx, msg.FromID, "image", bytes.NewReader(msg.Content), 0)
+	if err != nil {
+		log.Printf("保存内联图片失败, userId: %d, err: %v", msg.FromID, err)
+		return models.NewSendFailure(msg.ClientMsgID, models.SendErrUnavailable, "图片保存失败，请稍后重试")
+	}
+	msg.AttachmentID = attachment.ID
+	msg.Attachment = attachment
+	msg.Content = nil
+	return nil
+}
+
+// handleSync 处理客户端补齐请求，返回区间内的消息
+func (s *ChatService) handleSync(userID uint64, event *models.SyncEvent) []any {
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	messages, err := rpcClient.NewMessageProxy(conn).GetMessagesBySeq(userID, event.Type, event.TargetID, event.FromSeq, event.ToSeq, event.Limit)
+	if err != nil {
+		log.Printf("GetMessagesBySeq failed, userId: %d, err: %v", userID, err)
+		return []any{models.NewSendFailure("", models.SendErrInvalid, "同步消息失败")}
+	}
+	frames := make([]any, 0, len(messages))
+	for _, m := range messages {
+		frames = append(frames, conveter.ProtoToMessage(m))
+	}
+	return frames
+}
+
+// handleAck 处理客户端确认：移出未确认窗口并通知dbproxy已送达
+func (s *ChatService) handleAck(node *Node, userID uint64, event *models.AckEvent) {
+	acked := node.ack(event.MessageIDs)
+	if len(acked) == 0 {
+		return
+	}
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	if err := rpcClient.NewMessageProxy(conn).AckMessages(userID, acked); err != nil {
+		log.Printf("AckMessages failed, userId: %d, err: %v", userID, err)
+	}
+}
+
+// handleRead 处理客户端已读上报：清除未读记录并向原发送者推送回执
+func (s *ChatService) handleRead(ctx context.Context, userID uint64, event *models.ReadEvent) {
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	resp, err := rpcClient.NewMessageProxy(conn).MarkRead(userID, event.MessageIDs, event.PeerID, event.UpToID)
+	if err != nil {
+		log.Printf("MarkRead failed, userId: %d, err: %v", userID, err)
+		return
+	}
+	for _, r := range resp.Receipts {
+		receipt := &models.ReadReceipt{
+			Action:     models.ActionReceipt,
+			ReaderID:   userID,
+			MessageIDs: r.MessageIds,
+			ReadAt:     resp.ReadAt.AsTime(),
+		}
+		if err := s.publish(ctx, models.ActionReceipt, []uint64{r.FromId}, receipt, ""); err != nil {
+			log.Printf("发布已读回执失败: %v", err)
+		}
+	}
+}
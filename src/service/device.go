@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 设备类型
+const (
+	DeviceMobile  = "mobile"
+	DeviceDesktop = "desktop"
+	DeviceWeb     = "web"
+)
+
+// 默认设备策略：手机、桌面端各保留一个会话，网页端不限制
+var defaultDevicePolicy = map[string]int{
+	DeviceMobile:  1,
+	DeviceDesktop: 1,
+}
+
+// devicePolicy 每类设备允许同时在线的会话数，0 表示不限制
+// 可通过环境变量 DEVICE_POLICY 覆盖，格式如 "mobile:1,desktop:1,web:3"
+type devicePolicy map[string]int
+
+func loadDevicePolicy() devicePolicy {
+	policy := devicePolicy(defaultDevicePolicy)
+	if env := os.Getenv("DEVICE_POLICY"); env != "" {
+		parsed, err := parseDevicePolicy(env)
+		if err != nil {
+			log.Printf("DEVICE_POLICY 配置无效，使用默认策略: %v", err)
+		} else {
+			policy = parsed
+		}
+	}
+	return policy
+}
+
+func parseDevicePolicy(s string) (devicePolicy, error) {
+	policy := make(devicePolicy)
+	for _, item := range strings.Split(s, ",") {
+		class, limit, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok {
+			return nil, fmt.Errorf("无法解析: %q", item)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("会话数无效: %q", item)
+		}
+		policy[normalizeDeviceClass(class)] = n
+	}
+	return policy, nil
+}
+
+// limit 返回该类设备允许的会话数，0 表示不限制
+func (p devicePolicy) limit(class string) int {
+	return p[class]
+}
+
+// normalizeDeviceClass 未知的设备类型按网页端处理
+func normalizeDeviceClass(class string) string {
+	switch class = strings.ToLower(strings.TrimSpace(class)); class {
+	case DeviceMobile, DeviceDesktop, DeviceWeb:
+		return class
+	default:
+		return DeviceWeb
+	}
+}
+
+// deviceFromRequest 从连接参数中读取设备ID和设备类型
+// 未携带设备ID时生成一个仅本次连接有效的ID
+func deviceFromRequest(c *gin.Context, instanceID string) (deviceID, class string) {
+	deviceID = c.Query("device_id")
+	if deviceID == "" || len(deviceID) > 64 {
+		deviceID = fmt.Sprintf("%s-%d", instanceID, time.Now().UnixNano())
+	}
+	return deviceID, normalizeDeviceClass(c.Query("device_type"))
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseDevicePolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    devicePolicy
+		wantErr bool
+	}{
+		{in: "mobile:1,desktop:1,web:3", want: devicePolicy{DeviceMobile: 1, DeviceDesktop: 1, DeviceWeb: 3}},
+		{in: " Mobile : 2 , DESKTOP:0", want: devicePolicy{DeviceMobile: 2, DeviceDesktop: 0}},
+		// 未知的设备类型按网页端处理
+		{in: "tablet:2", want: devicePolicy{DeviceWeb: 2}},
+		{in: "mobile:1,mobile:2", want: devicePolicy{DeviceMobile: 2}},
+		{in: "mobile", wantErr: true},
+		{in: "mobile:one", wantErr: true},
+		{in: "mobile:-1", wantErr: true},
+		{in: "mobile:1,", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDevicePolicy(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDevicePolicy(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !maps.Equal(got, tt.want) {
+			t.Errorf("parseDevicePolicy(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadDevicePolicy(t *testing.T) {
+	tests := []struct {
+		env  string
+		want devicePolicy
+	}{
+		{env: "", want: devicePolicy(defaultDevicePolicy)},
+		{env: "web:2", want: devicePolicy{DeviceWeb: 2}},
+		// 配置无效时使用默认策略
+		{env: "mobile:x", want: devicePolicy(defaultDevicePolicy)},
+	}
+	for _, tt := range tests {
+		t.Setenv("DEVICE_POLICY", tt.env)
+		policy := loadDevicePolicy()
+		if !maps.Equal(policy, tt.want) {
+			t.Errorf("DEVICE_POLICY=%q: policy = %v, want %v", tt.env, policy, tt.want)
+		}
+		// 未列出的设备类型不限制
+		if tt.want[DeviceWeb] == 0 && policy.limit(DeviceWeb) != 0 {
+			t.Errorf("DEVICE_POLICY=%q: web limit = %d, want 0", tt.env, policy.limit(DeviceWeb))
+		}
+	}
+}
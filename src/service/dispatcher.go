@@ -0,0 +1,67 @@
+package service
+
+import (
+	"hash/fnv"
+	"time"
+
+	"github.com/hoyang/imserver/src/models"
+)
+
+// 分发协程数量、分发队列长度与入队超时
+const (
+	dispatchShards    = 16
+	dispatchQueueSize = 256
+	dispatchTimeout   = time.Second // 分发队列满时的最长等待时间
+)
+
+// dispatcher 按会话分片串行分发总线事件：
+// 同一会话的事件总是由同一个协程按到达顺序处理，不同会话之间并行
+type dispatcher struct {
+	shards []chan models.Envelope
+}
+
+func newDispatcher(handle func(models.Envelope)) *dispatcher {
+	d := &dispatcher{shards: make([]chan models.Envelope, dispatchShards)}
+	for i := range d.shards {
+		ch := make(chan models.Envelope, dispatchQueueSize)
+		d.shards[i] = ch
+		go func() {
+			for env := range ch {
+				handle(env)
+			}
+		}()
+	}
+	return d
+}
+
+// shard 事件所属分片的队列
+func (d *dispatcher) shard(key string) chan models.Envelope {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return d.shards[h.Sum32()%uint32(len(d.shards))]
+}
+
+// submit 将事件放入对应分片的队列，队列持续满载超过 dispatchTimeout 时放弃并返回 false
+// 只用于丢失后可以自行恢复的事件，聊天消息使用 submitWait
+func (d *dispatcher) submit(key string, env models.Envelope) bool {
+	shard := d.shard(key)
+	select {
+	case shard <- env:
+		return true
+	default:
+	}
+	timer := time.NewTimer(dispatchTimeout)
+	defer timer.Stop()
+	select {
+	case shard <- env:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// submitWait 将事件放入对应分片的队列，队列满时一直等待，从而阻塞订阅协程向上游施加背压
+// 分片只做非阻塞投递，不会被单个连接卡住，因此等待总是有限的
+func (d *dispatcher) submitWait(key string, env models.Envelope) {
+	d.shard(key) <- env
+}
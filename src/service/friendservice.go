@@ -0,0 +1,263 @@
+package service
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hoyang/imserver/src/conveter"
+	"github.com/hoyang/imserver/src/models"
+	rpcClient "github.com/hoyang/imserver/src/rpc"
+)
+
+type FriendReplyReq struct {
+	FromID uint64 `json:"fromID"`
+}
+
+// GetFriendRequests
+// @Summary 获取好友请求
+// @Tags 用户模块
+// @Produce json
+// @param direction query string false "incoming（默认，收到的请求）或 outgoing（发出的请求）"
+// @Success 200 {array} models.FriendRequestView
+// @Router /api/user/friendRequests [get]
+func (s *UserService) GetFriendRequests(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	outgoing := c.Query("direction") == "outgoing"
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	requests, err := rpcClient.NewUserProxy(conn).GetFriendRequests(userID.(uint64), outgoing)
+	if err != nil {
+		log.Printf("GetFriendRequests failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "获取好友请求失败"})
+		return
+	}
+	c.JSON(http.StatusOK, conveter.ProtosToFriendRequestViews(requests))
+}
+
+// AcceptFriend
+// @Summary 接受好友请求
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @param body body FriendReplyReq true "请求发起者ID"
+// @Success 200 {string} ok
+// @Router /api/user/acceptFriend [post]
+func (s *UserService) AcceptFriend(c *gin.Context) {
+	s.respondFriendRequest(c, true)
+}
+
+// RejectFriend
+// @Summary 拒绝好友请求
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @param body body FriendReplyReq true "请求发起者ID"
+// @Success 200 {string} ok
+// @Router /api/user/rejectFriend [post]
+func (s *UserService) RejectFriend(c *gin.Context) {
+	s.respondFriendRequest(c, false)
+}
+
+// respondFriendRequest 处理好友请求并通知请求发起者
+func (s *UserService) respondFriendRequest(c *gin.Context, accept bool) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req FriendReplyReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.FromID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	err := rpcClient.NewUserProxy(conn).RespondFriendRequest(userID.(uint64), req.FromID, accept)
+	s.pool.Put(conn)
+	if err != nil {
+		log.Printf("RespondFriendRequest failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "处理好友请求失败"})
+		return
+	}
+
+	event := &models.FriendEvent{Action: models.ActionFriendRejected, UserID: userID.(uint64)}
+	if accept {
+		event.Action = models.ActionFriendAccepted
+	}
+	if user, err := s.getUserByID(userID.(uint64)); err == nil {
+		event.Username = user.Name
+	}
+	if err := s.chatService.Notify(c, req.FromID, event.Action, event); err != nil {
+		log.Printf("推送好友请求结果失败: %v", err)
+	}
+
+	if accept {
+		c.JSON(http.StatusOK, gin.H{"message": "已接受好友请求"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已拒绝好友请求"})
+}
+
+type FriendIDReq struct {
+	FriendID uint64 `json:"friendID"`
+}
+
+type BlockReq struct {
+	UserID uint64 `json:"userID"`
+}
+
+type PrivacyReq struct {
+	FriendsOnly bool `json:"friendsOnly"`
+}
+
+// RemoveFriend
+// @Summary 删除好友
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @param body body FriendIDReq true "好友ID"
+// @Success 200 {string} ok
+// @Router /api/user/removeFriend [post]
+func (s *UserService) RemoveFriend(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req FriendIDReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.FriendID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	if err := rpcClient.NewUserProxy(conn).RemoveFriend(userID.(uint64), req.FriendID); err != nil {
+		log.Printf("RemoveFriend failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "删除好友失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已删除好友"})
+}
+
+// BlockUser
+// @Summary 拉黑用户
+// @Description 被拉黑的用户无法再发送私聊消息和好友请求
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @param body body BlockReq true "用户ID"
+// @Success 200 {string} ok
+// @Router /api/user/block [post]
+func (s *UserService) BlockUser(c *gin.Context) {
+	s.setBlocked(c, true)
+}
+
+// UnblockUser
+// @Summary 解除拉黑
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @param body body BlockReq true "用户ID"
+// @Success 200 {string} ok
+// @Router /api/user/unblock [post]
+func (s *UserService) UnblockUser(c *gin.Context) {
+	s.setBlocked(c, false)
+}
+
+func (s *UserService) setBlocked(c *gin.Context, block bool) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req BlockReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	proxy := rpcClient.NewUserProxy(conn)
+	if block {
+		if err := proxy.BlockUser(userID.(uint64), req.UserID); err != nil {
+			log.Printf("BlockUser failed %v\n", err)
+			c.JSON(httpStatusFromRPC(err), gin.H{"message": "拉黑失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "已拉黑"})
+		return
+	}
+	if err := proxy.UnblockUser(userID.(uint64), req.UserID); err != nil {
+		log.Printf("UnblockUser failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "解除拉黑失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已解除拉黑"})
+}
+
+// GetBlockedUsers
+// @Summary 获取黑名单
+// @Tags 用户模块
+// @Produce json
+// @Success 200 {array} models.FriendView
+// @Router /api/user/blocked [get]
+func (s *UserService) GetBlockedUsers(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	blocked, err := rpcClient.NewUserProxy(conn).GetBlockedUsers(userID.(uint64))
+	if err != nil {
+		log.Printf("GetBlockedUsers failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "获取黑名单失败"})
+		return
+	}
+	views := conveter.ProtosToFriendViews(blocked)
+	if views == nil {
+		views = []models.FriendView{}
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// SetPrivacy
+// @Summary 更新隐私设置
+// @Description friendsOnly 为 true 时只接收好友的私聊消息
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @param body body PrivacyReq true "隐私设置"
+// @Success 200 {string} ok
+// @Router /api/user/privacy [post]
+func (s *UserService) SetPrivacy(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req PrivacyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	if err := rpcClient.NewUserProxy(conn).SetPrivacy(userID.(uint64), req.FriendsOnly); err != nil {
+		log.Printf("SetPrivacy failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "更新隐私设置失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hoyang/imserver/src/conveter"
+	rpcClient "github.com/hoyang/imserver/src/rpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type CreateGroupReq struct {
+	Name      string   `json:"name"`
+	MemberIDs []uint64 `json:"memberIDs"`
+}
+
+type GroupMembersReq struct {
+	GroupID uint64   `json:"groupID"`
+	UserIDs []uint64 `json:"userIDs"`
+}
+
+type GroupReq struct {
+	GroupID uint64 `json:"groupID"`
+}
+
+// CreateGroup
+// @Summary 创建群组
+// @Tags 群组模块
+// @Accept json
+// @Produce json
+// @param body body CreateGroupReq true "群名称与初始成员"
+// @Success 200 {object} models.GroupView
+// @Router /api/group/create [post]
+func (s *UserService) CreateGroup(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req CreateGroupReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	group, err := rpcClient.NewGroupProxy(conn).CreateGroup(userID.(uint64), req.Name, req.MemberIDs)
+	if err != nil {
+		log.Printf("CreateGroup failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "创建群组失败"})
+		return
+	}
+	c.JSON(http.StatusOK, conveter.ProtoToGroupView(group))
+}
+
+// DissolveGroup
+// @Summary 解散群组
+// @Tags 群组模块
+// @Accept json
+// @Produce json
+// @param body body GroupReq true "群组ID"
+// @Success 200 {string} ok
+// @Router /api/group/dissolve [post]
+func (s *UserService) DissolveGroup(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req GroupReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.GroupID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	if err := rpcClient.NewGroupProxy(conn).DissolveGroup(userID.(uint64), req.GroupID); err != nil {
+		log.Printf("DissolveGroup failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "解散群组失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// AddGroupMembers
+// @Summary 添加群成员
+// @Tags 群组模块
+// @Accept json
+// @Produce json
+// @param body body GroupMembersReq true "群组ID与成员ID"
+// @Success 200 {string} ok
+// @Router /api/group/addMembers [post]
+func (s *UserService) AddGroupMembers(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req GroupMembersReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.GroupID == 0 || len(req.UserIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	if err := rpcClient.NewGroupProxy(conn).AddMembers(userID.(uint64), req.GroupID, req.UserIDs); err != nil {
+		log.Printf("AddMembers failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "添加群成员失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// RemoveGroupMembers
+// @Summary 移除群成员或退出群组
+// @Tags 群组模块
+// @Accept json
+// @Produce json
+// @param body body GroupMembersReq true "群组ID与成员ID"
+// @Success 200 {string} ok
+// @Router /api/group/removeMembers [post]
+func (s *UserService) RemoveGroupMembers(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req GroupMembersReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.GroupID == 0 || len(req.UserIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	if err := rpcClient.NewGroupProxy(conn).RemoveMembers(userID.(uint64), req.GroupID, req.UserIDs); err != nil {
+		log.Printf("RemoveMembers failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "移除群成员失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// GetGroups
+// @Summary 获取我加入的群组
+// @Tags 群组模块
+// @Produce json
+// @Success 200 {array} models.GroupView
+// @Router /api/group/list [get]
+func (s *UserService) GetGroups(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	groups, err := rpcClient.NewGroupProxy(conn).GetUserGroups(userID.(uint64))
+	if err != nil {
+		log.Printf("GetUserGroups failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "获取群组失败"})
+		return
+	}
+	c.JSON(http.StatusOK, conveter.ProtosToGroupViews(groups))
+}
+
+// GetGroupMembers
+// @Summary 获取群成员
+// @Tags 群组模块
+// @Produce json
+// @param id query string true "群组ID"
+// @Success 200 {array} uint64
+// @Router /api/group/members [get]
+func (s *UserService) GetGroupMembers(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	groupID, err := strconv.ParseUint(c.Query("id"), 10, 64)
+	if err != nil || groupID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "无效的群组ID"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	memberIDs, err := rpcClient.NewGroupProxy(conn).GetGroupMembers(groupID)
+	if err != nil {
+		log.Printf("GetGroupMembers failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "获取群成员失败"})
+		return
+	}
+	if !slices.Contains(memberIDs, userID.(uint64)) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "非群成员"})
+		return
+	}
+	c.JSON(http.StatusOK, memberIDs)
+}
+
+// httpStatusFromRPC 将 gRPC 错误码映射为 HTTP 状态码
+func httpStatusFromRPC(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.AlreadyExists:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
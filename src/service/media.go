@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/hoyang/imserver/src/conveter"
+	"github.com/hoyang/imserver/src/media"
+	"github.com/hoyang/imserver/src/models"
+	rpcClient "github.com/hoyang/imserver/src/rpc"
+	"github.com/hoyang/imserver/src/storage"
+)
+
+// 缩略图规格：名称 -> 最长边像素，小图由中图再缩小得到
+const (
+	ThumbSmall  = "small"
+	ThumbMedium = "medium"
+)
+
+var thumbSizes = map[string]int{
+	ThumbSmall:  160,
+	ThumbMedium: 480,
+}
+
+// MediaStore 媒体文件入库：写入存储、提取元数据、生成缩略图并登记附件
+// HTTP 上传和 WebSocket 内联图片共用
+type MediaStore struct {
+	pool    *rpcClient.ClientPool
+	storage storage.Storage
+}
+
+func NewMediaStore(pool *rpcClient.ClientPool, store storage.Storage) *MediaStore {
+	return &MediaStore{pool: pool, storage: store}
+}
+
+// thumbKey 缩略图在存储中的键
+func thumbKey(sum, size string) string {
+	return "thumb/" + sum[:2] + "/" + sum + "_" + size + ".jpg"
+}
+
+// Save 计算哈希、检测类型，按内容寻址写入存储后再登记附件
+// durationMs 为客户端声明的语音时长，能从文件解析出时长时以解析结果为准
+func (m *MediaStore) Save(ctx context.Context, ownerID uint64, fileName string, f io.ReadSeeker, durationMs int64) (*models.Attachment, error) {
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return nil, err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	// 以内容检测类型，不信任客户端声明的 Content-Type
+	head := make([]byte, 512)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	mimeType := http.DetectContentType(head[:n])
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// 按内容寻址，相同内容（例如转发到多个会话的同一文件）只存储一份
+	key := "media/" + sum[:2] + "/" + sum
+	if !m.exists(ctx, key) {
+		if err := m.storage.Put(ctx, key, f); err != nil {
+			return nil, err
+		}
+	}
+
+	attachment := &models.Attachment{
+		OwnerID:    ownerID,
+		FileName:   sanitizeFileName(fileName),
+		MimeType:   mimeType,
+		Size:       size,
+		SHA256:     sum,
+		StorageKey: key,
+	}
+	// 元数据提取失败不影响上传，客户端按普通文件展示
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		if err := m.processImage(ctx, attachment, f); err != nil {
+			log.Printf("处理图片失败, sha256: %s, err: %v", sum, err)
+		}
+	case strings.HasPrefix(mimeType, "audio/"):
+		attachment.DurationMs = max(durationMs, 0)
+		if mimeType == "audio/wave" {
+			if _, err := f.Seek(0, io.SeekStart); err == nil {
+				if d, err := media.WAVDuration(f); err == nil {
+					attachment.DurationMs = d.Milliseconds()
+				}
+			}
+		}
+	}
+
+	conn := m.pool.Get()
+	defer m.pool.Put(conn)
+	created, err := rpcClient.NewMediaProxy(conn).CreateAttachment(conveter.ToPBAttachment(attachment))
+	if err != nil {
+		return nil, err
+	}
+	return conveter.ToDBAttachment(created), nil
+}
+
+// exists 存储中是否已有该对象
+func (m *MediaStore) exists(ctx context.Context, key string) bool {
+	obj, err := m.storage.Open(ctx, key)
+	if err != nil {
+		return false
+	}
+	obj.Close()
+	return true
+}
+
+// processImage 记录图片尺寸并生成各规格的缩略图
+func (m *MediaStore) processImage(ctx context.Context, attachment *models.Attachment, f io.ReadSeeker) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	width, height, err := media.ImageInfo(f)
+	if err != nil {
+		return err
+	}
+	attachment.Width, attachment.Height = int32(width), int32(height)
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	img, err := media.Decode(f)
+	if err != nil {
+		return err
+	}
+	medium := media.Thumbnail(img, thumbSizes[ThumbMedium])
+	small := media.Thumbnail(medium, thumbSizes[ThumbSmall])
+	for size, thumb := range map[string]image.Image{ThumbMedium: medium, ThumbSmall: small} {
+		data, err := media.EncodeJPEG(thumb)
+		if err != nil {
+			return err
+		}
+		if err := m.storage.Put(ctx, thumbKey(attachment.SHA256, size), bytes.NewReader(data)); err != nil {
+			return err
+		}
+	}
+	attachment.HasThumbnails = true
+	return nil
+}
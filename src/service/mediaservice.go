@@ -0,0 +1,299 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hoyang/imserver/src/conveter"
+	"github.com/hoyang/imserver/src/models"
+	rpcClient "github.com/hoyang/imserver/src/rpc"
+	"github.com/hoyang/imserver/src/storage"
+	"github.com/hoyang/imserver/src/utils"
+)
+
+// 单个文件的大小上限
+const maxUploadSize = 20 << 20
+
+// 签名下载链接的有效期
+const mediaURLTTL = time.Hour
+
+// UploadMedia
+// @Summary 上传媒体文件
+// @Description multipart 上传单个文件（字段名 file），返回附件信息；发送图片、语音等消息时在 AttachmentId 中引用附件ID
+// @Description 图片返回宽高并生成缩略图，语音返回时长
+// @Tags 媒体模块
+// @Accept multipart/form-data
+// @Produce json
+// @param file formData file true "文件，最大20MB"
+// @param duration formData int false "语音时长（毫秒）"
+// @Success 200 {object} models.Attachment
+// @Router /api/media/upload [post]
+func (s *UserService) UploadMedia(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+
+	// 多留1MB给multipart的边界和其他字段
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize+1<<20)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "文件过大"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if fh.Size > maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "文件过大"})
+		return
+	}
+	if fh.Size == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文件为空"})
+		return
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	defer f.Close()
+
+	// 语音时长由客户端录制时给出，WAV 文件以解析结果为准
+	durationMs, _ := strconv.ParseInt(c.PostForm("duration"), 10, 64)
+	attachment, err := s.media.Save(c, userID.(uint64), fh.Filename, f, durationMs)
+	if err != nil {
+		log.Printf("保存附件失败, userID: %v, err: %v", userID, err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "上传失败"})
+		return
+	}
+	c.JSON(http.StatusOK, attachment)
+}
+
+// sanitizeFileName 只保留文件名部分并限制长度
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		name = "file"
+	}
+	if r := []rune(name); len(r) > 255 {
+		name = string(r[len(r)-255:])
+	}
+	return name
+}
+
+// inlineMIME 可在浏览器中直接展示的类型，其他类型（包括可执行脚本的 SVG、HTML）一律作为下载
+func inlineMIME(mimeType string) bool {
+	if strings.HasPrefix(mimeType, "image/svg") {
+		return false
+	}
+	return strings.HasPrefix(mimeType, "image/") || strings.HasPrefix(mimeType, "audio/") || strings.HasPrefix(mimeType, "video/")
+}
+
+// DownloadMedia
+// @Summary 下载媒体文件
+// @Description 只有上传者和引用该附件的消息的参与者可以下载，支持 Range 请求
+// @Tags 媒体模块
+// @Produce octet-stream
+// @param id path uint64 true "附件ID"
+// @Success 200 {file} file
+// @Router /api/media/{id} [get]
+func (s *UserService) DownloadMedia(c *gin.Context) {
+	attachment, ok := s.authorizedAttachment(c)
+	if !ok {
+		return
+	}
+	s.serveAttachment(c, attachment, attachment.StorageKey, attachment.MimeType, attachment.FileName, attachment.SHA256)
+}
+
+// DownloadThumbnail
+// @Summary 下载图片缩略图
+// @Description 权限与下载原图相同；size 为 small（最长边160）或 medium（最长边480），统一为 JPEG
+// @Tags 媒体模块
+// @Produce jpeg
+// @param id path uint64 true "附件ID"
+// @param size query string false "缩略图规格 small|medium，默认 small"
+// @Success 200 {file} file
+// @Router /api/media/{id}/thumb [get]
+func (s *UserService) DownloadThumbnail(c *gin.Context) {
+	size := c.DefaultQuery("size", ThumbSmall)
+	if _, ok := thumbSizes[size]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "无效的缩略图规格"})
+		return
+	}
+	attachment, ok := s.authorizedAttachment(c)
+	if !ok {
+		return
+	}
+	s.serveVariant(c, attachment, size)
+}
+
+// GetMediaURL
+// @Summary 获取附件的签名下载链接
+// @Description 链接不依赖登录态，可用于跨域的 img 标签和下载工具，过期前有效；持有者失去访问权限（如消息被撤回）后链接随之失效
+// @Tags 媒体模块
+// @Produce json
+// @param id path uint64 true "附件ID"
+// @param size query string false "缩略图规格 small|medium，不指定时为原文件"
+// @Success 200 {object} models.SignedURL
+// @Router /api/media/{id}/url [get]
+func (s *UserService) GetMediaURL(c *gin.Context) {
+	size := c.Query("size")
+	if _, ok := thumbSizes[size]; size != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "无效的缩略图规格"})
+		return
+	}
+	attachment, ok := s.authorizedAttachment(c)
+	if !ok {
+		return
+	}
+	if size != "" && !attachment.HasThumbnails {
+		c.JSON(http.StatusNotFound, gin.H{"message": "该附件没有缩略图"})
+		return
+	}
+
+	userID := c.GetUint64("user_id")
+	expiresAt := time.Now().Add(mediaURLTTL).Truncate(time.Second)
+	query := url.Values{}
+	query.Set("uid", strconv.FormatUint(userID, 10))
+	query.Set("exp", strconv.FormatInt(expiresAt.Unix(), 10))
+	if size != "" {
+		query.Set("size", size)
+	}
+	query.Set("sig", utils.SignMedia(attachment.ID, userID, size, expiresAt))
+	c.JSON(http.StatusOK, &models.SignedURL{
+		URL:       "/media/" + strconv.FormatUint(attachment.ID, 10) + "?" + query.Encode(),
+		ExpiresAt: expiresAt,
+	})
+}
+
+// DownloadSignedMedia
+// @Summary 通过签名链接下载附件
+// @Description 无需登录，校验签名和有效期，并重新校验签发对象的访问权限；支持 Range 请求
+// @Tags 媒体模块
+// @Produce octet-stream
+// @param id path uint64 true "附件ID"
+// @param uid query uint64 true "签发对象的用户ID"
+// @param exp query int64 true "过期时间（Unix秒）"
+// @param size query string false "缩略图规格"
+// @param sig query string true "签名"
+// @Success 200 {file} file
+// @Router /media/{id} [get]
+func (s *UserService) DownloadSignedMedia(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "无效的附件ID"})
+		return
+	}
+	userID, err1 := strconv.ParseUint(c.Query("uid"), 10, 64)
+	expiresAt, err2 := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusForbidden, gin.H{"message": "链接无效"})
+		return
+	}
+	size := c.Query("size")
+	if err := utils.VerifyMedia(id, userID, size, expiresAt, c.Query("sig")); err != nil {
+		if errors.Is(err, utils.ErrSignatureExpired) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "链接已过期"})
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{"message": "链接无效"})
+		return
+	}
+
+	conn := s.pool.Get()
+	pbAttachment, err := rpcClient.NewMediaProxy(conn).GetAttachment(id, userID)
+	s.pool.Put(conn)
+	if err != nil {
+		log.Printf("GetAttachment failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "获取附件失败"})
+		return
+	}
+
+	// 链接本身即凭证，允许其他源直接引用；缓存不超过链接的有效期
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Cache-Control", "private, max-age="+strconv.FormatInt(max(expiresAt-time.Now().Unix(), 0), 10))
+	s.serveVariant(c, conveter.ToDBAttachment(pbAttachment), size)
+}
+
+// serveVariant 返回原文件（size 为空）或指定规格的缩略图
+func (s *UserService) serveVariant(c *gin.Context, attachment *models.Attachment, size string) {
+	if size == "" {
+		s.serveAttachment(c, attachment, attachment.StorageKey, attachment.MimeType, attachment.FileName, attachment.SHA256)
+		return
+	}
+	if _, ok := thumbSizes[size]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "无效的缩略图规格"})
+		return
+	}
+	if !attachment.HasThumbnails {
+		c.JSON(http.StatusNotFound, gin.H{"message": "该附件没有缩略图"})
+		return
+	}
+	name := strings.TrimSuffix(attachment.FileName, filepath.Ext(attachment.FileName)) + "_" + size + ".jpg"
+	s.serveAttachment(c, attachment, thumbKey(attachment.SHA256, size), "image/jpeg", name, attachment.SHA256+"_"+size)
+}
+
+// authorizedAttachment 解析路径中的附件ID并校验当前用户的访问权限，失败时已写入响应
+func (s *UserService) authorizedAttachment(c *gin.Context) (*models.Attachment, bool) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "无效的附件ID"})
+		return nil, false
+	}
+
+	conn := s.pool.Get()
+	pbAttachment, err := rpcClient.NewMediaProxy(conn).GetAttachment(id, userID.(uint64))
+	s.pool.Put(conn)
+	if err != nil {
+		log.Printf("GetAttachment failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "获取附件失败"})
+		return nil, false
+	}
+	return conveter.ToDBAttachment(pbAttachment), true
+}
+
+// serveAttachment 从存储读取附件（或其缩略图）并返回给客户端
+func (s *UserService) serveAttachment(c *gin.Context, attachment *models.Attachment, key, mimeType, fileName, etag string) {
+	obj, err := s.media.storage.Open(c, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "文件不存在"})
+			return
+		}
+		log.Printf("读取附件失败, key: %s, err: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "读取文件失败"})
+		return
+	}
+	defer obj.Close()
+
+	disposition := "attachment"
+	if inlineMIME(mimeType) {
+		disposition = "inline"
+	}
+	c.Header("Content-Type", mimeType)
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": fileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	if c.Writer.Header().Get("Cache-Control") == "" {
+		c.Header("Cache-Control", "private, max-age=86400")
+	}
+	c.Header("ETag", `"`+etag+`"`)
+	http.ServeContent(c.Writer, c.Request, fileName, attachment.CreatedAt, obj)
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hoyang/imserver/src/conveter"
+	"github.com/hoyang/imserver/src/models"
+	im "github.com/hoyang/imserver/src/proto"
+	rpcClient "github.com/hoyang/imserver/src/rpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type HistoryQuery struct {
+	PeerID   uint64 `form:"peerId" binding:"required"`
+	BeforeID uint64 `form:"before"`
+	AfterID  uint64 `form:"after"`
+	Limit    int32  `form:"limit"`
+}
+
+type GroupHistoryQuery struct {
+	GroupID  uint64 `form:"groupId" binding:"required"`
+	BeforeID uint64 `form:"before"`
+	Limit    int32  `form:"limit"`
+}
+
+// 时间按 RFC3339 格式，如 2024-01-02T15:04:05+08:00
+type SearchQuery struct {
+	Query       string    `form:"q" binding:"required"`
+	PeerID      uint64    `form:"peerId"`
+	GroupID     uint64    `form:"groupId"`
+	SenderID    uint64    `form:"senderId"`
+	ContentType *int32    `form:"contentType"`
+	Start       time.Time `form:"start"`
+	End         time.Time `form:"end"`
+	BeforeID    uint64    `form:"before"`
+	Limit       int32     `form:"limit"`
+}
+
+type ConversationsQuery struct {
+	Archived bool  `form:"archived"`
+	Offset   int32 `form:"offset"`
+	Limit    int32 `form:"limit"`
+}
+
+// 未设置的标记保持不变
+type UpdateConversationReq struct {
+	Type        im.MessageType `json:"type" binding:"required"`
+	TargetID    uint64         `json:"targetId" binding:"required"`
+	Pinned      *bool          `json:"pinned"`
+	Hidden      *bool          `json:"hidden"`
+	Archived    *bool          `json:"archived"`
+	ClearUnread bool           `json:"clearUnread"`
+}
+
+type RecallMessageReq struct {
+	MessageID uint64 `json:"messageId" binding:"required"`
+}
+
+type EditMessageReq struct {
+	MessageID uint64 `json:"messageId" binding:"required"`
+	Content   []byte `json:"content" binding:"required"` // base64 编码，与消息的 Content 字段一致
+}
+
+// GetHistory
+// @Summary 获取私聊历史
+// @Description 返回与对方之间双向的消息，按ID升序；before 向前翻页，after 向后翻页，都不指定时返回最新的消息
+// @Tags 消息模块
+// @Produce json
+// @param peerId query uint64 true "会话对方ID"
+// @param before query uint64 false "返回ID小于该值的消息"
+// @param after query uint64 false "返回ID大于该值的消息"
+// @param limit query int false "数量，默认50，最大200"
+// @Success 200 {object} models.MessagePage
+// @Router /api/message/history [get]
+func (s *UserService) GetHistory(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req HistoryQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	// 只能查询自己参与的会话：请求者固定为当前用户
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	messages, hasMore, err := rpcClient.NewMessageProxy(conn).
+		GetConversationMessages(userID.(uint64), req.PeerID, req.BeforeID, req.AfterID, req.Limit)
+	if err != nil {
+		log.Printf("GetConversationMessages failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "获取历史消息失败"})
+		return
+	}
+
+	page := models.MessagePage{Messages: make([]models.Message, len(messages)), HasMore: hasMore}
+	for i, m := range messages {
+		page.Messages[i] = conveter.ProtoToMessage(m)
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetGroupHistory
+// @Summary 获取群聊历史
+// @Description 只有群成员可以查询；返回的消息按ID升序，before 向前翻页，不指定时返回最新的消息
+// @Tags 消息模块
+// @Produce json
+// @param groupId query uint64 true "群组ID"
+// @param before query uint64 false "返回ID小于该值的消息"
+// @param limit query int false "数量，默认50，最大200"
+// @Success 200 {object} models.MessagePage
+// @Router /api/message/groupHistory [get]
+func (s *UserService) GetGroupHistory(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req GroupHistoryQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	messages, hasMore, err := rpcClient.NewMessageProxy(conn).
+		GetGroupMessages(userID.(uint64), req.GroupID, req.BeforeID, req.Limit)
+	if err != nil {
+		log.Printf("GetGroupMessages failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "获取群聊历史失败"})
+		return
+	}
+
+	page := models.MessagePage{Messages: make([]models.Message, len(messages)), HasMore: hasMore}
+	for i, m := range messages {
+		page.Messages[i] = conveter.ProtoToMessage(m)
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// SearchMessages
+// @Summary 检索消息
+// @Description 在自己参与的私聊和群聊中检索文本消息，多个关键词用空格分隔且须同时出现；按时间倒序，before 向前翻页
+// @Tags 消息模块
+// @Produce json
+// @param q query string true "关键词"
+// @param peerId query uint64 false "只检索与该用户的私聊"
+// @param groupId query uint64 false "只检索该群"
+// @param senderId query uint64 false "只检索该用户发送的消息"
+// @param contentType query int false "内容类型"
+// @param start query string false "起始时间（RFC3339）"
+// @param end query string false "结束时间（RFC3339）"
+// @param before query uint64 false "返回ID小于该值的结果"
+// @param limit query int false "数量，默认20，最大100"
+// @Success 200 {object} models.SearchResult
+// @Router /api/message/search [get]
+func (s *UserService) SearchMessages(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req SearchQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	// 检索者固定为当前用户，只能检索自己参与的会话
+	searchReq := &im.SearchMessagesRequest{
+		UserId:   userID.(uint64),
+		Query:    req.Query,
+		PeerId:   req.PeerID,
+		GroupId:  req.GroupID,
+		SenderId: req.SenderID,
+		BeforeId: req.BeforeID,
+		Limit:    req.Limit,
+	}
+	if req.ContentType != nil {
+		contentType := im.ContentType(*req.ContentType)
+		searchReq.ContentType = &contentType
+	}
+	if !req.Start.IsZero() {
+		searchReq.StartTime = timestamppb.New(req.Start)
+	}
+	if !req.End.IsZero() {
+		searchReq.EndTime = timestamppb.New(req.End)
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	hits, hasMore, err := rpcClient.NewMessageProxy(conn).SearchMessages(searchReq)
+	if err != nil {
+		log.Printf("SearchMessages failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "检索消息失败"})
+		return
+	}
+
+	result := models.SearchResult{Hits: make([]models.SearchHit, len(hits)), HasMore: hasMore}
+	for i, h := range hits {
+		result.Hits[i] = conveter.ProtoToSearchHit(h)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetConversations
+// @Summary 获取会话列表
+// @Description 置顶的会话在前，其余按最后活跃时间倒序；不含隐藏的会话
+// @Tags 消息模块
+// @Produce json
+// @param archived query bool false "true 时返回归档的会话"
+// @param offset query int false "偏移量"
+// @param limit query int false "数量，默认50，最大200"
+// @Success 200 {array} models.ConversationView
+// @Router /api/message/conversations [get]
+func (s *UserService) GetConversations(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req ConversationsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	convs, err := rpcClient.NewMessageProxy(conn).ListConversations(userID.(uint64), req.Archived, req.Offset, req.Limit)
+	if err != nil {
+		log.Printf("ListConversations failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "获取会话列表失败"})
+		return
+	}
+	c.JSON(http.StatusOK, conveter.ProtosToConversationViews(convs))
+}
+
+// UpdateConversation
+// @Summary 更新会话
+// @Description 置顶、隐藏、归档会话，或清零未读数；隐藏的会话收到新消息后重新显示
+// @Tags 消息模块
+// @Accept json
+// @Produce json
+// @param body body UpdateConversationReq true "会话与要更新的标记"
+// @Success 200 {object} models.ConversationView
+// @Router /api/message/conversations/update [post]
+func (s *UserService) UpdateConversation(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req UpdateConversationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	defer s.pool.Put(conn)
+	conv, err := rpcClient.NewMessageProxy(conn).UpdateConversation(&im.UpdateConversationRequest{
+		UserId:      userID.(uint64),
+		Type:        req.Type,
+		TargetId:    req.TargetID,
+		Pinned:      req.Pinned,
+		Hidden:      req.Hidden,
+		Archived:    req.Archived,
+		ClearUnread: req.ClearUnread,
+	})
+	if err != nil {
+		log.Printf("UpdateConversation failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "更新会话失败"})
+		return
+	}
+	c.JSON(http.StatusOK, conveter.ProtoToConversationView(conv))
+}
+
+// RecallMessage
+// @Summary 撤回消息
+// @Description 只能撤回自己发送的消息，且需在撤回时限内；会话参与者收到 recall 事件
+// @Tags 消息模块
+// @Accept json
+// @Produce json
+// @param body body RecallMessageReq true "消息ID"
+// @Success 200 {object} models.Message
+// @Router /api/message/recall [post]
+func (s *UserService) RecallMessage(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req RecallMessageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	msg, err := rpcClient.NewMessageProxy(conn).RecallMessage(userID.(uint64), req.MessageID)
+	s.pool.Put(conn)
+	if err != nil {
+		log.Printf("RecallMessage failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "撤回消息失败: " + status.Convert(err).Message()})
+		return
+	}
+
+	recalled := conveter.ProtoToMessage(msg)
+	if err := s.chatService.BroadcastChange(c, models.ActionRecall, &recalled); err != nil {
+		log.Printf("推送撤回事件失败: %v", err)
+	}
+	c.JSON(http.StatusOK, recalled)
+}
+
+// EditMessage
+// @Summary 编辑消息
+// @Description 只能编辑自己发送且未撤回的文本消息（不含附件），内容不超过65535字节；编辑前的内容保存为编辑历史，会话参与者收到 edit 事件
+// @Tags 消息模块
+// @Accept json
+// @Produce json
+// @param body body EditMessageReq true "消息ID与新内容"
+// @Success 200 {object} models.Message
+// @Router /api/message/edit [post]
+func (s *UserService) EditMessage(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req EditMessageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	conn := s.pool.Get()
+	msg, err := rpcClient.NewMessageProxy(conn).EditMessage(userID.(uint64), req.MessageID, req.Content)
+	s.pool.Put(conn)
+	if err != nil {
+		log.Printf("EditMessage failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "编辑消息失败: " + status.Convert(err).Message()})
+		return
+	}
+
+	edited := conveter.ProtoToMessage(msg)
+	if err := s.chatService.BroadcastChange(c, models.ActionEdit, &edited); err != nil {
+		log.Printf("推送编辑事件失败: %v", err)
+	}
+	c.JSON(http.StatusOK, edited)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hoyang/imserver/src/models"
+)
+
+// 队列长度，待推送队列满说明客户端消费过慢
+const (
+	dataQueueSize    = 256
+	controlQueueSize = 16
+)
+
+// 消息确认参数
+const (
+	ackTimeout     = 10 * time.Second // 等待客户端ack的超时时间
+	maxRetransmit  = 3                // 最大重传次数，超过后断开连接等待重连补发
+	inflightWindow = 64               // 未确认消息的窗口大小
+)
+
+var errAckTimeout = errors.New("客户端确认超时")
+
+// inflightMsg 已推送但尚未被客户端确认的消息
+type inflightMsg struct {
+	msg     models.Message
+	sentAt  time.Time
+	retries int
+}
+
+type Node struct {
+	Conn        *websocket.Conn
+	UserID      uint64
+	DeviceID    string // 设备ID，同一用户的多个连接以此区分
+	DeviceClass string // 设备类型，用于同类设备互踢策略
+	TokenID     string // 建立连接所用访问令牌的jti，令牌吊销时据此断开连接
+
+	DataQueue chan any // 待推送给客户端的帧（消息或事件）
+	// 连接建立时补发的离线消息和未确认消息，由补发协程写入，补发完毕后关闭
+	BacklogQueue chan models.Message
+	// 回复给本连接的控制帧，不受未确认窗口限制，避免读协程被阻塞
+	ControlQueue chan any
+	wg           sync.WaitGroup
+
+	inflightMu sync.Mutex
+	inflight   map[uint64]*inflightMsg
+	ackNotify  chan struct{} // 收到ack后唤醒写协程
+
+	// 补发期间推送过的消息ID，补发与实时投递的同一条消息只推送先到的一次
+	// 只由写协程访问
+	seen        map[uint64]struct{}
+	backlogDone bool
+
+	closeNotify chan struct{} // 连接关闭通知
+	closeOnce   sync.Once
+}
+
+func CreateNode(c *websocket.Conn) *Node {
+	var node Node
+	node.DataQueue = make(chan any, dataQueueSize)
+	node.ControlQueue = make(chan any, controlQueueSize)
+	node.Conn = c
+	node.inflight = make(map[uint64]*inflightMsg)
+	node.BacklogQueue = make(chan models.Message)
+	node.seen = make(map[uint64]struct{})
+	node.ackNotify = make(chan struct{}, 1)
+	node.closeNotify = make(chan struct{})
+	return &node
+}
+
+// close 通知各协程退出并关闭连接，可重复调用
+func (node *Node) close() {
+	node.closeOnce.Do(func() {
+		close(node.closeNotify)
+		// 关闭连接以唤醒阻塞在 ReadMessage 的读协程
+		node.Conn.Close()
+	})
+}
+
+// kick 推送下线通知，写协程发出通知后关闭连接；控制队列已满时直接关闭连接
+// 由分发协程调用，不能阻塞
+func (node *Node) kick(frame *models.Kicked) {
+	select {
+	case node.ControlQueue <- frame:
+	case <-node.closeNotify:
+	default:
+		node.close()
+	}
+}
+
+// enqueue 非阻塞地放入待推送队列，由分发协程调用，不能因单个连接阻塞同一分片上的其他会话
+// 队列已满时断开连接：未送达的私聊消息在重连后作为离线消息补发，群消息由客户端按序列号缺口补齐
+func (node *Node) enqueue(frame any) bool {
+	select {
+	case node.DataQueue <- frame:
+		return true
+	case <-node.closeNotify:
+		return false
+	default:
+		log.Printf("推送队列已满，断开连接, userID: %d, device: %s", node.UserID, node.DeviceID)
+		node.close()
+		return false
+	}
+}
+
+// write 将帧写入连接，带ID的消息进入未确认窗口
+func (node *Node) write(frame any) error {
+	if msg, ok := frame.(models.Message); ok && msg.ID != 0 {
+		node.inflightMu.Lock()
+		node.inflight[msg.ID] = &inflightMsg{msg: msg, sentAt: time.Now()}
+		node.inflightMu.Unlock()
+	}
+	content, err := json.Marshal(frame)
+	if err != nil {
+		return err
+	}
+	return node.Conn.WriteMessage(websocket.TextMessage, content)
+}
+
+// pushBacklog 将补发的消息交给写协程，连接已关闭时返回 false
+func (node *Node) pushBacklog(msg models.Message) bool {
+	select {
+	case node.BacklogQueue <- msg:
+		return true
+	case <-node.closeNotify:
+		return false
+	}
+}
+
+// duplicate 补发的消息与登记连接后实时投递的消息可能重复，先到的推送，后到的跳过
+// 补发期间记录推送过的消息ID，补发结束后只用于过滤迟到的重复，命中即移除
+func (node *Node) duplicate(frame any) bool {
+	msg, ok := frame.(models.Message)
+	if !ok || msg.ID == 0 {
+		return false
+	}
+	if _, ok := node.seen[msg.ID]; ok {
+		delete(node.seen, msg.ID)
+		return true
+	}
+	if !node.backlogDone {
+		node.seen[msg.ID] = struct{}{}
+	}
+	return false
+}
+
+// ack 移除已确认的消息，返回确实处于窗口中的消息ID
+func (node *Node) ack(ids []uint64) []uint64 {
+	node.inflightMu.Lock()
+	acked := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := node.inflight[id]; ok {
+			delete(node.inflight, id)
+			acked = append(acked, id)
+		}
+	}
+	node.inflightMu.Unlock()
+
+	select {
+	case node.ackNotify <- struct{}{}:
+	default:
+	}
+	return acked
+}
+
+// windowFull 未确认消息是否已达到窗口上限
+func (node *Node) windowFull() bool {
+	node.inflightMu.Lock()
+	defer node.inflightMu.Unlock()
+	return len(node.inflight) >= inflightWindow
+}
+
+// retransmit 重传超时未确认的消息，超过最大重传次数返回 errAckTimeout
+func (node *Node) retransmit() error {
+	now := time.Now()
+	var expired []models.Message
+	node.inflightMu.Lock()
+	for _, m := range node.inflight {
+		if now.Sub(m.sentAt) < ackTimeout {
+			continue
+		}
+		if m.retries >= maxRetransmit {
+			node.inflightMu.Unlock()
+			return errAckTimeout
+		}
+		m.retries++
+		m.sentAt = now
+		expired = append(expired, m.msg)
+	}
+	node.inflightMu.Unlock()
+
+	for _, msg := range expired {
+		content, err := json.Marshal(&msg)
+		if err != nil {
+			return err
+		}
+		if err := node.Conn.WriteMessage(websocket.TextMessage, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unacked 返回连接断开时仍未确认的消息
+func (node *Node) unacked() []models.Message {
+	node.inflightMu.Lock()
+	defer node.inflightMu.Unlock()
+	msgs := make([]models.Message, 0, len(node.inflight))
+	for _, m := range node.inflight {
+		msgs = append(msgs, m.msg)
+	}
+	slices.SortFunc(msgs, func(a, b models.Message) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return msgs
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/hoyang/imserver/src/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// 检查心跳过期用户的间隔
+const presenceSweepInterval = 30 * time.Second
+
+// Presence 基于redis的在线状态，各实例共享
+// 在线用户记录在一个有序集合中，score 为过期时间（Unix秒），由心跳续期
+type Presence struct {
+	redis    *redis.Client
+	registry *ConnRegistry
+}
+
+func NewPresence(redis *redis.Client, registry *ConnRegistry) *Presence {
+	return &Presence{redis: redis, registry: registry}
+}
+
+// Touch 标记用户在线并续期，返回用户是否由离线变为在线
+func (p *Presence) Touch(ctx context.Context, userID uint64) (bool, error) {
+	added, err := p.redis.ZAdd(ctx, utils.PresenceKey(), redis.Z{
+		Score:  float64(time.Now().Add(registryTTL).Unix()),
+		Member: userID,
+	}).Result()
+	return added > 0, err
+}
+
+// Leave 用户在本实例上的连接全部断开后调用，其他实例上也无连接时标记离线
+// 返回用户是否由在线变为离线
+func (p *Presence) Leave(ctx context.Context, userID uint64) (bool, error) {
+	instances, err := p.registry.Lookup(ctx, []uint64{userID})
+	if err != nil {
+		return false, err
+	}
+	if len(instances) > 0 {
+		return false, nil
+	}
+	removed, err := p.redis.ZRem(ctx, utils.PresenceKey(), userID).Result()
+	return removed > 0, err
+}
+
+// Expired 找出心跳已过期的用户（实例异常退出或连接静默断开）并标记离线
+// 多个实例同时检查时，每个用户只会由一个实例返回
+func (p *Presence) Expired(ctx context.Context) ([]uint64, error) {
+	members, err := p.redis.ZRangeByScore(ctx, utils.PresenceKey(), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	var offline []uint64
+	for _, m := range members {
+		userID, err := strconv.ParseUint(m, 10, 64)
+		if err != nil {
+			continue
+		}
+		left, err := p.Leave(ctx, userID)
+		if err != nil {
+			return offline, err
+		}
+		if left {
+			offline = append(offline, userID)
+		}
+	}
+	return offline, nil
+}
+
+// OnlineMap 批量查询用户是否在线
+func (p *Presence) OnlineMap(ctx context.Context, userIDs []uint64) (map[uint64]bool, error) {
+	pipe := p.redis.Pipeline()
+	cmds := make([]*redis.FloatCmd, len(userIDs))
+	for i, id := range userIDs {
+		cmds[i] = pipe.ZScore(ctx, utils.PresenceKey(), strconv.FormatUint(id, 10))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	now := float64(time.Now().Unix())
+	online := make(map[uint64]bool, len(userIDs))
+	for i, cmd := range cmds {
+		online[userIDs[i]] = cmd.Err() == nil && cmd.Val() >= now
+	}
+	return online, nil
+}
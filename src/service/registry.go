@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hoyang/imserver/src/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// 连接注册的有效期，由WebSocket心跳续期
+const registryTTL = 2 * pingPeriod
+
+// ConnRegistry 基于redis的连接注册表：user -> 持有其连接的实例ID
+// 每个用户一个hash，field为实例ID，value为该登记的过期时间（Unix秒）
+type ConnRegistry struct {
+	redis      *redis.Client
+	instanceID string
+}
+
+func NewConnRegistry(redis *redis.Client, instanceID string) *ConnRegistry {
+	return &ConnRegistry{redis: redis, instanceID: instanceID}
+}
+
+// instanceID 当前实例ID，优先使用环境变量 SERVER_ID
+func instanceID() string {
+	if id := os.Getenv("SERVER_ID"); id != "" {
+		return id
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Register 登记用户连接在本实例上，心跳时重复调用以续期（同时续期该类设备的会话集合）
+func (r *ConnRegistry) Register(ctx context.Context, userID uint64, deviceClass string) error {
+	key := utils.ConnRegistryKey(userID)
+	pipe := r.redis.TxPipeline()
+	pipe.HSet(ctx, key, r.instanceID, time.Now().Add(registryTTL).Unix())
+	pipe.Expire(ctx, key, registryTTL)
+	pipe.Expire(ctx, utils.DeviceSessionsKey(userID, deviceClass), registryTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// AddSession 记录该类设备的新会话，返回超出 limit 需要踢下线的旧会话设备ID
+func (r *ConnRegistry) AddSession(ctx context.Context, userID uint64, deviceClass, deviceID string, limit int) ([]string, error) {
+	key := utils.DeviceSessionsKey(userID, deviceClass)
+	pipe := r.redis.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(time.Now().UnixMilli()), Member: deviceID})
+	evicted := pipe.ZRevRange(ctx, key, int64(limit), -1)
+	pipe.ZRemRangeByRank(ctx, key, 0, int64(-limit-1))
+	pipe.Expire(ctx, key, registryTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return evicted.Val(), nil
+}
+
+// RemoveSession 会话断开时移出设备会话集合
+func (r *ConnRegistry) RemoveSession(ctx context.Context, userID uint64, deviceClass, deviceID string) error {
+	return r.redis.ZRem(ctx, utils.DeviceSessionsKey(userID, deviceClass), deviceID).Err()
+}
+
+// Unregister 用户在本实例上已无连接时注销
+func (r *ConnRegistry) Unregister(ctx context.Context, userID uint64) error {
+	return r.redis.HDel(ctx, utils.ConnRegistryKey(userID), r.instanceID).Err()
+}
+
+// Lookup 查询用户所在的实例，返回 实例ID -> 该实例上的用户ID
+func (r *ConnRegistry) Lookup(ctx context.Context, userIDs []uint64) (map[string][]uint64, error) {
+	pipe := r.redis.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(userIDs))
+	for i, id := range userIDs {
+		cmds[i] = pipe.HGetAll(ctx, utils.ConnRegistryKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	instances := make(map[string][]uint64)
+	for i, cmd := range cmds {
+		for instance, expireAt := range cmd.Val() {
+			// 跳过未续期的登记（实例异常退出）
+			if ts, err := strconv.ParseInt(expireAt, 10, 64); err != nil || ts < now {
+				continue
+			}
+			instances[instance] = append(instances[instance], userIDs[i])
+		}
+	}
+	return instances, nil
+}
@@ -4,9 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"log"
-	"math/rand"
 	"net/http"
 	"strconv"
 	"time"
@@ -16,6 +14,7 @@ import (
 	"github.com/hoyang/imserver/src/models"
 	im "github.com/hoyang/imserver/src/proto"
 	rpcClient "github.com/hoyang/imserver/src/rpc"
+	"github.com/hoyang/imserver/src/storage"
 	"gorm.io/gorm"
 
 	"github.com/hoyang/imserver/src/utils"
@@ -26,13 +25,19 @@ type UserService struct {
 	pool        *rpcClient.ClientPool
 	redisDB     *redis.Client
 	chatService *ChatService
+	media       *MediaStore     // 媒体文件入库与存储
+	uploads     *UploadSessions // 分片上传会话
 }
 
 // NewUserService 构造函数
-func NewUserService(pool *rpcClient.ClientPool, redisDB *redis.Client) *UserService {
-	chatService := NewChatService(redisDB, pool)
+func NewUserService(pool *rpcClient.ClientPool, redisDB *redis.Client, store storage.Storage) *UserService {
+	media := NewMediaStore(pool, store)
+	chatService := NewChatService(redisDB, pool, media)
 	chatService.Subscription()
-	return &UserService{pool: pool, redisDB: redisDB, chatService: chatService}
+	chatService.WatchPresence()
+	uploads := NewUploadSessions(redisDB, store)
+	uploads.WatchExpired()
+	return &UserService{pool: pool, redisDB: redisDB, chatService: chatService, media: media, uploads: uploads}
 }
 
 // GetIndex
@@ -84,12 +89,22 @@ func (s *UserService) Login(c *gin.Context) {
 		})
 		return
 	}
-	if !utils.VaildPassword(password, dbUser.Salt, dbUser.Password) {
+	ok, needsRehash := utils.VerifyPassword(password, dbUser.Salt, dbUser.Password)
+	if !ok {
 		c.JSON(400, gin.H{
 			"message": "登录失败",
 		})
 		return
 	}
+	// 旧的MD5哈希或参数过低的哈希，登录成功时透明升级
+	if needsRehash {
+		if hash, err := utils.HashPassword(password); err != nil {
+			log.Printf("升级密码哈希失败: %v", err)
+		} else {
+			dbUser.Password = hash
+			dbUser.Salt = ""
+		}
+	}
 	dbUser.IsLogout = false
 	now := time.Now()
 	dbUser.LoginTime = &now
@@ -98,11 +113,33 @@ func (s *UserService) Login(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "更新用户登录状态失败"})
 		return
 	}
-	token, err := utils.GenerateToken(uint64(dbUser.ID))
+	token, refreshToken, err := issueTokens(c, uint64(dbUser.ID))
 	if err != nil {
 		c.JSON(500, gin.H{"error": "生成Token失败"})
 		return
 	}
+	c.JSON(200, gin.H{
+		"message":      "ok",
+		"userID":       dbUser.ID,
+		"token":        token,
+		"refreshToken": refreshToken,
+		"expiresIn":    int(utils.AccessTokenTTL.Seconds()),
+	})
+}
+
+// 刷新令牌 Cookie 只在 /api 下发送（刷新和登出时需要）
+const refreshCookiePath = "/api"
+
+// issueTokens 签发访问令牌和刷新令牌并写入 Cookie
+func issueTokens(c *gin.Context, userID uint64) (string, string, error) {
+	token, err := utils.GenerateToken(userID)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err := utils.GenerateRefreshToken(userID)
+	if err != nil {
+		return "", "", err
+	}
 
 	http.SetCookie(c.Writer, &http.Cookie{
 		Name:     "token",
@@ -111,10 +148,105 @@ func (s *UserService) Login(c *gin.Context) {
 		HttpOnly: false, // 防止XSS攻击
 		Secure:   false, // 开发环境设为false，生产环境设为true
 	})
-	c.JSON(200, gin.H{
-		"message": "ok",
-		"userID":  dbUser.ID,
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Path:     refreshCookiePath,
+		MaxAge:   int(utils.RefreshTokenTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   false, // 开发环境设为false，生产环境设为true
+		SameSite: http.SameSiteStrictMode,
 	})
+	return token, refreshToken, nil
+}
+
+// clearTokenCookies 删除访问令牌和刷新令牌 Cookie
+func clearTokenCookies(c *gin.Context) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "token",
+		Value:    "",
+		Path:     "/",
+		HttpOnly: false,
+		Secure:   false,
+		MaxAge:   -1,
+	})
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Path:     refreshCookiePath,
+		HttpOnly: true,
+		Secure:   false,
+		MaxAge:   -1,
+	})
+}
+
+type RefreshReq struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Refresh
+// @Summary 刷新访问令牌
+// @Description 使用刷新令牌（Cookie refresh_token 或请求体）换取新的访问令牌，刷新令牌同时轮换
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @param body body RefreshReq false "刷新令牌（未使用 Cookie 时）"
+// @Success 200 {string} ok
+// @Router /api/refresh [post]
+func (s *UserService) Refresh(c *gin.Context) {
+	refreshToken, err := c.Cookie("refresh_token")
+	if err != nil || refreshToken == "" {
+		var req RefreshReq
+		if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少刷新令牌"})
+			return
+		}
+		refreshToken = req.RefreshToken
+	}
+
+	claims, err := utils.ParseToken(refreshToken, utils.TokenTypeRefresh)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "刷新令牌无效或已过期"})
+		return
+	}
+	// 轮换：旧的刷新令牌只能使用一次，并发请求中只有一个能换到新令牌
+	if err := utils.ConsumeToken(c, claims); err != nil {
+		if errors.Is(err, utils.ErrTokenRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "刷新令牌无效或已过期"})
+			return
+		}
+		log.Printf("吊销刷新令牌失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "刷新令牌失败"})
+		return
+	}
+
+	token, newRefreshToken, err := issueTokens(c, claims.UserID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "生成Token失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "ok",
+		"token":        token,
+		"refreshToken": newRefreshToken,
+		"expiresIn":    int(utils.AccessTokenTTL.Seconds()),
+	})
+}
+
+// JWKS
+// @Summary 令牌验证公钥
+// @Description 以 JWKS 格式导出非对称签名密钥的公钥，供其他服务验证访问令牌
+// @Tags 用户模块
+// @Produce json
+// @Success 200 {object} map[string]any
+// @Router /.well-known/jwks.json [get]
+func (s *UserService) JWKS(c *gin.Context) {
+	jwks, err := utils.JWKS()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "加载密钥失败"})
+		return
+	}
+	c.JSON(http.StatusOK, jwks)
 }
 
 // @Router /logout [post]
@@ -149,15 +281,41 @@ func (s *UserService) Logout(c *gin.Context) {
 		return
 	}
 
+	// 吊销本次会话的访问令牌和刷新令牌，并断开用该令牌建立的连接
+	if claims, ok := c.Get("claims"); ok {
+		accessClaims := claims.(*utils.Claims)
+		if err := utils.RevokeToken(c, accessClaims); err != nil {
+			log.Printf("吊销访问令牌失败: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "登出失败"})
+			return
+		}
+		if err := s.chatService.DisconnectToken(c, accessClaims.UserID, accessClaims.ID, "已登出"); err != nil {
+			log.Printf("断开已登出会话的连接失败: %v", err)
+		}
+	}
+	// 刷新令牌与 Refresh 一样可能来自 Cookie 或请求体，两处都吊销
+	var refreshTokens []string
+	if refreshToken, err := c.Cookie("refresh_token"); err == nil && refreshToken != "" {
+		refreshTokens = append(refreshTokens, refreshToken)
+	}
+	var req RefreshReq
+	if err := c.ShouldBindJSON(&req); err == nil && req.RefreshToken != "" {
+		refreshTokens = append(refreshTokens, req.RefreshToken)
+	}
+	for _, refreshToken := range refreshTokens {
+		refreshClaims, err := utils.ParseToken(refreshToken, utils.TokenTypeRefresh)
+		if err != nil || refreshClaims.UserID != userID.(uint64) {
+			continue
+		}
+		if err := utils.RevokeToken(c, refreshClaims); err != nil {
+			log.Printf("吊销刷新令牌失败: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "登出失败"})
+			return
+		}
+	}
+
 	// 删除 Cookie 中的 Token
-	http.SetCookie(c.Writer, &http.Cookie{
-		Name:     "token",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: false,
-		Secure:   false,
-		MaxAge:   -1,
-	})
+	clearTokenCookies(c)
 
 	c.JSON(http.StatusOK, gin.H{"message": "登出成功"})
 }
@@ -179,6 +337,18 @@ func (s *UserService) GetFriends(c *gin.Context) {
 		})
 		return
 	}
+	// 在线状态以presence为准
+	ids := make([]uint64, len(friends))
+	for i, f := range friends {
+		ids[i] = uint64(f.ID)
+	}
+	if online, err := s.chatService.OnlineMap(c, ids); err != nil {
+		log.Printf("查询好友在线状态失败: %v", err)
+	} else {
+		for i := range friends {
+			friends[i].Online = online[uint64(friends[i].ID)]
+		}
+	}
 	prin, _ := json.Marshal(friends)
 	log.Println(string(prin))
 	c.JSON(200, friends)
@@ -188,9 +358,24 @@ type AddFriendReq struct {
 	Username       string `json:"username"`
 	UserID         uint64 `json:"userID"`
 	FriendUsername string `json:"friendUsername"`
+	Note           string `json:"note" binding:"max=255"`
 }
 
+// AddFriend
+// @Summary 发送好友请求
+// @Description 对方已向自己发出请求时直接成为好友
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @param body body AddFriendReq true "好友用户名与附言"
+// @Success 200 {string} ok
+// @Router /api/user/addfriend [post]
 func (s *UserService) AddFriend(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
 	var addFriendReq AddFriendReq
 	if err := c.ShouldBindJSON(&addFriendReq); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
@@ -206,22 +391,42 @@ func (s *UserService) AddFriend(c *gin.Context) {
 		})
 		return
 	}
+	// 请求发起者以认证身份为准
 	var userShips models.Contact
-	userShips.UserID = addFriendReq.UserID
+	userShips.UserID = userID.(uint64)
 	userShips.FriendID = uint64(friend.ID)
-	err = s.addFriend(&userShips)
+	userShips.Note = addFriendReq.Note
+	accepted, err := s.addFriend(&userShips)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		c.JSON(httpStatusFromRPC(err), gin.H{
 			"message": "添加好友失败",
 		})
 		return
 	}
+
+	// 通知对方：收到新请求，或双方互加直接成为好友
+	event := &models.FriendEvent{Action: models.ActionFriendRequest, UserID: userShips.UserID, Note: userShips.Note}
+	if accepted {
+		event.Action = models.ActionFriendAccepted
+	}
+	if self, err := s.getUserByID(userShips.UserID); err == nil {
+		event.Username = self.Name
+	}
+	if err := s.chatService.Notify(c, userShips.FriendID, event.Action, event); err != nil {
+		log.Printf("推送好友请求通知失败: %v", err)
+	}
+
+	if accepted {
+		c.JSON(http.StatusOK, gin.H{"message": "已成为好友", "accepted": true})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"message": "添加好友成功",
+		"message": "好友请求已发送",
 	})
 }
 
-func (s *UserService) addFriend(userShips *models.Contact) error {
+// addFriend 发送好友请求，返回是否已直接成为好友
+func (s *UserService) addFriend(userShips *models.Contact) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
@@ -231,13 +436,14 @@ func (s *UserService) addFriend(userShips *models.Contact) error {
 	contact := im.Contact{}
 	contact.UserID = uint64(userShips.UserID)
 	contact.FriendID = uint64(userShips.FriendID)
-	_, err := client.AddFriend(ctx, &contact)
+	contact.Note = userShips.Note
+	resp, err := client.AddFriend(ctx, &contact)
 	if err != nil {
 		log.Printf("addFriend failed %v\n", err)
-		return err
+		return false, err
 	}
 
-	return nil
+	return resp.Accepted, nil
 }
 
 // Register
@@ -265,15 +471,18 @@ func (s *UserService) Register(c *gin.Context) {
 		return
 	}
 	password := loginRequest.Password
-	salt := fmt.Sprintf("%06d", rand.Int31())
-	user.Password = utils.MakePassword(password, salt)
-	user.Salt = salt
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		c.JSON(500, gin.H{"message": "注册失败"})
+		return
+	}
+	user.Password = hash
 	Phone := c.PostForm("phone")
 	user.Phone = &Phone
 	email := c.PostForm("email")
 	user.Email = &email
 
-	err := s.createUser(&user)
+	err = s.createUser(&user)
 	if err != nil {
 		c.JSON(400, gin.H{
 			"message": "注册失败",
@@ -361,9 +570,11 @@ func (s *UserService) GetUserByID(c *gin.Context) {
 // UpdateUser
 // @Summary 更新用户
 // @Tags 用户模块
-// @param id formData string false "Id"
+// @Description 只能更新自己的信息；修改密码时须提供原密码，成功后此前签发的令牌全部失效
+// @param id formData string false "Id（可省略，须与当前用户一致）"
 // @param username formData string false "用户名"
-// @param password formData string false "密码"
+// @param password formData string false "新密码"
+// @param oldPassword formData string false "原密码（修改密码时必填）"
 // @param phone formData string false "手机号"
 // @param email formData string false "邮箱"
 // @param Authorization header  string true "Bearer token"
@@ -371,9 +582,21 @@ func (s *UserService) GetUserByID(c *gin.Context) {
 // @Success 200 {string} ok
 // @Router /user/updateUser [post]
 func (s *UserService) UpdateUser(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	// 用户ID以认证身份为准，表单中的 id 只允许是自己
+	if formID := c.PostForm("id"); formID != "" {
+		if id, err := strconv.ParseUint(formID, 10, 64); err != nil || id != userID.(uint64) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "只能修改自己的信息"})
+			return
+		}
+	}
+
 	user := models.IMUser{}
-	id, _ := strconv.Atoi(c.PostForm("id"))
-	user.ID = uint(id)
+	user.ID = uint(userID.(uint64))
 	user.Name = c.PostForm("username")
 	password := c.PostForm("password")
 	Phone := c.PostForm("phone")
@@ -382,9 +605,22 @@ func (s *UserService) UpdateUser(c *gin.Context) {
 	user.Email = &email
 
 	if password != "" {
-		salt := fmt.Sprintf("%06d", rand.Int31())
-		user.Password = utils.MakePassword(password, salt)
-		user.Salt = salt
+		// 修改密码须验证原密码
+		dbUser, err := s.getUserByID(userID.(uint64))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "更新失败"})
+			return
+		}
+		if ok, _ := utils.VerifyPassword(c.PostForm("oldPassword"), dbUser.Salt, dbUser.Password); !ok {
+			c.JSON(http.StatusForbidden, gin.H{"message": "原密码错误"})
+			return
+		}
+		hash, err := utils.HashPassword(password)
+		if err != nil {
+			c.JSON(500, gin.H{"message": "更新失败"})
+			return
+		}
+		user.Password = hash
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
@@ -403,12 +639,78 @@ func (s *UserService) UpdateUser(c *gin.Context) {
 	}
 	user = *conveter.ToDBIMUser(result)
 
+	// 修改密码后此前签发的令牌全部失效，已建立的连接也要断开
+	if password != "" {
+		if err := utils.RevokeUserTokens(c, uint64(user.ID)); err != nil {
+			log.Printf("吊销用户令牌失败: %v", err)
+		}
+		if err := s.chatService.Disconnect(c, uint64(user.ID), "密码已修改"); err != nil {
+			log.Printf("断开用户连接失败: %v", err)
+		}
+	}
+
 	c.JSON(200, gin.H{
 		"message": "ok",
 		"userId":  user.ID,
 	})
 }
 
+type DeleteUserReq struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// DeleteUser
+// @Summary 注销账号
+// @Description 需要确认密码；注销后好友关系被解除，已发送的消息保留但匿名化
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @param body body DeleteUserReq true "当前密码"
+// @Success 200 {string} ok
+// @Router /api/user/delete [post]
+func (s *UserService) DeleteUser(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req DeleteUserReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	dbUser, err := s.getUserByID(userID.(uint64))
+	if err != nil {
+		c.JSON(httpStatusFromRPC(err), gin.H{"error": "查询用户信息失败"})
+		return
+	}
+	if ok, _ := utils.VerifyPassword(req.Password, dbUser.Salt, dbUser.Password); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "密码错误"})
+		return
+	}
+
+	conn := s.pool.Get()
+	err = rpcClient.NewUserProxy(conn).DeleteUser(userID.(uint64))
+	s.pool.Put(conn)
+	if err != nil {
+		log.Printf("DeleteUser failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"error": "注销账号失败"})
+		return
+	}
+
+	// 吊销该账号的全部令牌，并断开在所有实例上的连接
+	if err := utils.RevokeUserTokens(c, userID.(uint64)); err != nil {
+		log.Printf("吊销已注销用户的令牌失败: %v", err)
+	}
+	if err := s.chatService.Disconnect(c, userID.(uint64), "账号已注销"); err != nil {
+		log.Printf("断开已注销用户的连接失败: %v", err)
+	}
+
+	clearTokenCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "账号已注销"})
+}
+
 // UpgradeWebSocket
 // @Summary 升级websocket
 // @Description Handle WebSocket upgrade request
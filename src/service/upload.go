@@ -0,0 +1,254 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hoyang/imserver/src/models"
+	"github.com/hoyang/imserver/src/storage"
+	"github.com/hoyang/imserver/src/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// 分片上传的限制
+const (
+	maxChunkedUploadSize = 200 << 20 // 分片上传的文件大小上限
+	defaultChunkSize     = 1 << 20
+	minChunkSize         = 64 << 10
+	maxChunkSize         = 5 << 20
+	uploadSessionTTL     = 24 * time.Hour // 每收到一个分片续期
+	uploadSweepInterval  = 10 * time.Minute
+)
+
+var (
+	ErrUploadNotFound   = errors.New("upload session not found")
+	ErrUploadIncomplete = errors.New("upload has missing chunks")
+	ErrUploadMismatch   = errors.New("uploaded content does not match declared hash")
+	ErrChunkInvalid     = errors.New("invalid chunk index or size")
+)
+
+// UploadSessions 分片上传会话，会话状态保存在redis，分片写入存储后端，各实例共享
+// 同一文件的分片可以经由不同实例上传
+type UploadSessions struct {
+	redis   *redis.Client
+	storage storage.Storage
+}
+
+func NewUploadSessions(redis *redis.Client, store storage.Storage) *UploadSessions {
+	return &UploadSessions{redis: redis, storage: store}
+}
+
+// chunkKey 分片在存储中的键
+func chunkKey(uploadID string, index int) string {
+	return "uploads/" + uploadID + "/" + strconv.Itoa(index)
+}
+
+// chunkLength 第 index 片应有的字节数
+func chunkLength(session *models.UploadSession, index int) int64 {
+	if index == session.TotalChunks-1 {
+		return session.Size - int64(index)*session.ChunkSize
+	}
+	return session.ChunkSize
+}
+
+// Create 创建上传会话，chunkSize 为 0 时使用默认值
+func (u *UploadSessions) Create(ctx context.Context, session *models.UploadSession) error {
+	if session.ChunkSize == 0 {
+		session.ChunkSize = defaultChunkSize
+	}
+	session.ChunkSize = min(max(session.ChunkSize, minChunkSize), maxChunkSize)
+	session.TotalChunks = int((session.Size + session.ChunkSize - 1) / session.ChunkSize)
+	session.Received = []int{}
+	session.ExpiresAt = time.Now().Add(uploadSessionTTL)
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	session.ID = hex.EncodeToString(id)
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	pipe := u.redis.TxPipeline()
+	pipe.Set(ctx, utils.UploadSessionKey(session.ID), data, uploadSessionTTL)
+	pipe.ZAdd(ctx, utils.PendingUploadsKey(), redis.Z{
+		Score:  float64(session.ExpiresAt.Unix()),
+		Member: pendingMember(session),
+	})
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// pendingMember 待清理集合的成员，带上分片数以便会话过期后仍能删除分片
+func pendingMember(session *models.UploadSession) string {
+	return session.ID + ":" + strconv.Itoa(session.TotalChunks)
+}
+
+// Get 获取用户自己的上传会话及已接收的分片，不存在或不属于该用户时返回 ErrUploadNotFound
+func (u *UploadSessions) Get(ctx context.Context, ownerID uint64, uploadID string) (*models.UploadSession, error) {
+	data, err := u.redis.Get(ctx, utils.UploadSessionKey(uploadID)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrUploadNotFound
+		}
+		return nil, err
+	}
+	var session models.UploadSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	if session.OwnerID != ownerID {
+		return nil, ErrUploadNotFound
+	}
+
+	members, err := u.redis.SMembers(ctx, utils.UploadChunksKey(uploadID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	session.Received = make([]int, 0, len(members))
+	for _, m := range members {
+		if index, err := strconv.Atoi(m); err == nil {
+			session.Received = append(session.Received, index)
+		}
+	}
+	slices.Sort(session.Received)
+	return &session, nil
+}
+
+// PutChunk 写入一个分片并续期会话，重复上传同一分片时覆盖
+// 除最后一片外每片必须正好 ChunkSize 字节
+func (u *UploadSessions) PutChunk(ctx context.Context, session *models.UploadSession, index int, r io.Reader) error {
+	if index < 0 || index >= session.TotalChunks {
+		return ErrChunkInvalid
+	}
+	want := chunkLength(session, index)
+	data, err := io.ReadAll(io.LimitReader(r, want+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != want {
+		return ErrChunkInvalid
+	}
+	if err := u.storage.Put(ctx, chunkKey(session.ID, index), bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(uploadSessionTTL)
+	pipe := u.redis.TxPipeline()
+	pipe.SAdd(ctx, utils.UploadChunksKey(session.ID), index)
+	pipe.Expire(ctx, utils.UploadChunksKey(session.ID), uploadSessionTTL)
+	pipe.Expire(ctx, utils.UploadSessionKey(session.ID), uploadSessionTTL)
+	pipe.ZAdd(ctx, utils.PendingUploadsKey(), redis.Z{
+		Score:  float64(expiresAt.Unix()),
+		Member: pendingMember(session),
+	})
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Assemble 按序拼接全部分片到临时文件并校验大小和哈希，调用方负责关闭并删除返回的文件
+// 校验失败时返回 ErrUploadMismatch，分片保留，客户端可重传出错的部分后再次完成
+func (u *UploadSessions) Assemble(ctx context.Context, session *models.UploadSession) (*os.File, error) {
+	if len(session.Received) != session.TotalChunks {
+		return nil, ErrUploadIncomplete
+	}
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*os.File, error) {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	hash := sha256.New()
+	w := io.MultiWriter(tmp, hash)
+	var size int64
+	for index := range session.TotalChunks {
+		obj, err := u.storage.Open(ctx, chunkKey(session.ID, index))
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return fail(ErrUploadIncomplete)
+			}
+			return fail(err)
+		}
+		n, err := io.Copy(w, obj)
+		obj.Close()
+		if err != nil {
+			return fail(err)
+		}
+		size += n
+	}
+	if size != session.Size || hex.EncodeToString(hash.Sum(nil)) != session.SHA256 {
+		return fail(ErrUploadMismatch)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fail(err)
+	}
+	return tmp, nil
+}
+
+// Delete 删除会话及其分片
+func (u *UploadSessions) Delete(ctx context.Context, session *models.UploadSession) error {
+	pipe := u.redis.TxPipeline()
+	pipe.Del(ctx, utils.UploadSessionKey(session.ID), utils.UploadChunksKey(session.ID))
+	pipe.ZRem(ctx, utils.PendingUploadsKey(), pendingMember(session))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	u.deleteChunks(ctx, session.ID, session.TotalChunks)
+	return nil
+}
+
+func (u *UploadSessions) deleteChunks(ctx context.Context, uploadID string, total int) {
+	for index := range total {
+		if err := u.storage.Delete(ctx, chunkKey(uploadID, index)); err != nil {
+			log.Printf("删除分片失败, uploadId: %s, index: %d, err: %v", uploadID, index, err)
+		}
+	}
+}
+
+// WatchExpired 定期删除过期会话残留的分片
+// 多个实例同时检查时，每个会话只由成功移出集合的实例清理
+func (u *UploadSessions) WatchExpired() {
+	go func() {
+		ticker := time.NewTicker(uploadSweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx := context.Background()
+			members, err := u.redis.ZRangeByScore(ctx, utils.PendingUploadsKey(), &redis.ZRangeBy{
+				Min: "-inf",
+				Max: strconv.FormatInt(time.Now().Unix(), 10),
+			}).Result()
+			if err != nil {
+				log.Printf("检查过期上传失败: %v", err)
+				continue
+			}
+			for _, m := range members {
+				removed, err := u.redis.ZRem(ctx, utils.PendingUploadsKey(), m).Result()
+				if err != nil || removed == 0 {
+					continue
+				}
+				uploadID, total, _ := strings.Cut(m, ":")
+				if n, err := strconv.Atoi(total); err == nil {
+					u.deleteChunks(ctx, uploadID, n)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hoyang/imserver/src/conveter"
+	"github.com/hoyang/imserver/src/models"
+	rpcClient "github.com/hoyang/imserver/src/rpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type InitUploadReq struct {
+	FileName   string `json:"fileName" binding:"required"`
+	Size       int64  `json:"size" binding:"required,gt=0"`
+	SHA256     string `json:"sha256" binding:"required,len=64,hexadecimal"` // 整个文件的内容哈希
+	ChunkSize  int64  `json:"chunkSize"`                                    // 期望的分片大小，服务端可能调整
+	DurationMs int64  `json:"durationMs"`                                   // 语音时长（毫秒）
+}
+
+// InitUpload
+// @Summary 创建分片上传会话
+// @Description 声明文件大小和SHA-256后按返回的 chunkSize 分片上传；自己上传过相同内容时直接返回 attachment（秒传），不创建会话
+// @Tags 媒体模块
+// @Accept json
+// @Produce json
+// @param data body InitUploadReq true "文件信息"
+// @Success 200 {object} models.UploadSession
+// @Router /api/media/uploads [post]
+func (s *UserService) InitUpload(c *gin.Context) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return
+	}
+	var req InitUploadReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	if req.Size > maxChunkedUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "文件过大"})
+		return
+	}
+	sum := strings.ToLower(req.SHA256)
+
+	conn := s.pool.Get()
+	existing, err := rpcClient.NewMediaProxy(conn).FindAttachmentByHash(userID.(uint64), sum, req.Size)
+	s.pool.Put(conn)
+	if err == nil {
+		c.JSON(http.StatusOK, &models.UploadSession{
+			OwnerID:    userID.(uint64),
+			FileName:   existing.FileName,
+			Size:       existing.Size,
+			SHA256:     existing.Sha256,
+			Received:   []int{},
+			Attachment: conveter.ToDBAttachment(existing),
+		})
+		return
+	}
+	if status.Code(err) != codes.NotFound {
+		log.Printf("FindAttachmentByHash failed %v\n", err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "创建上传失败"})
+		return
+	}
+
+	session := &models.UploadSession{
+		OwnerID:    userID.(uint64),
+		FileName:   sanitizeFileName(req.FileName),
+		Size:       req.Size,
+		SHA256:     sum,
+		ChunkSize:  req.ChunkSize,
+		DurationMs: req.DurationMs,
+	}
+	if err := s.uploads.Create(c, session); err != nil {
+		log.Printf("创建上传会话失败, userID: %v, err: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "创建上传失败"})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// GetUpload
+// @Summary 查询分片上传进度
+// @Description 断线重连后据 received 续传缺失的分片
+// @Tags 媒体模块
+// @Produce json
+// @param uploadId path string true "上传会话ID"
+// @Success 200 {object} models.UploadSession
+// @Router /api/media/uploads/{uploadId} [get]
+func (s *UserService) GetUpload(c *gin.Context) {
+	session, ok := s.ownUpload(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// PutUploadChunk
+// @Summary 上传一个分片
+// @Description 请求体为分片的原始字节；除最后一片外每片必须正好 chunkSize 字节，重复上传同一分片时覆盖
+// @Tags 媒体模块
+// @Accept octet-stream
+// @Produce json
+// @param uploadId path string true "上传会话ID"
+// @param index path int true "分片序号，从0开始"
+// @Success 200 {object} map[string]string
+// @Router /api/media/uploads/{uploadId}/chunks/{index} [put]
+func (s *UserService) PutUploadChunk(c *gin.Context) {
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "无效的分片序号"})
+		return
+	}
+	session, ok := s.ownUpload(c)
+	if !ok {
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxChunkSize+1)
+	if err := s.uploads.PutChunk(c, session, index, body); err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, ErrChunkInvalid), errors.As(err, &tooLarge):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "分片序号或大小不正确"})
+		default:
+			log.Printf("保存分片失败, uploadId: %s, index: %d, err: %v", session.ID, index, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "保存分片失败"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// CompleteUpload
+// @Summary 完成分片上传
+// @Description 拼接全部分片并校验大小和SHA-256，通过后登记附件；校验失败时返回409，分片保留可重传
+// @Tags 媒体模块
+// @Produce json
+// @param uploadId path string true "上传会话ID"
+// @Success 200 {object} models.Attachment
+// @Router /api/media/uploads/{uploadId}/complete [post]
+func (s *UserService) CompleteUpload(c *gin.Context) {
+	session, ok := s.ownUpload(c)
+	if !ok {
+		return
+	}
+
+	f, err := s.uploads.Assemble(c, session)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUploadIncomplete):
+			c.JSON(http.StatusConflict, gin.H{"message": "分片未上传完整", "received": session.Received})
+		case errors.Is(err, ErrUploadMismatch):
+			c.JSON(http.StatusConflict, gin.H{"message": "文件校验失败"})
+		default:
+			log.Printf("拼接分片失败, uploadId: %s, err: %v", session.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "上传失败"})
+		}
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	attachment, err := s.media.Save(c, session.OwnerID, session.FileName, f, session.DurationMs)
+	if err != nil {
+		log.Printf("保存附件失败, uploadId: %s, err: %v", session.ID, err)
+		c.JSON(httpStatusFromRPC(err), gin.H{"message": "上传失败"})
+		return
+	}
+	if err := s.uploads.Delete(c, session); err != nil {
+		log.Printf("删除上传会话失败, uploadId: %s, err: %v", session.ID, err)
+	}
+	c.JSON(http.StatusOK, attachment)
+}
+
+// CancelUpload
+// @Summary 取消分片上传
+// @Description 删除上传会话和已上传的分片
+// @Tags 媒体模块
+// @Produce json
+// @param uploadId path string true "上传会话ID"
+// @Success 200 {object} map[string]string
+// @Router /api/media/uploads/{uploadId} [delete]
+func (s *UserService) CancelUpload(c *gin.Context) {
+	session, ok := s.ownUpload(c)
+	if !ok {
+		return
+	}
+	if err := s.uploads.Delete(c, session); err != nil {
+		log.Printf("删除上传会话失败, uploadId: %s, err: %v", session.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "取消失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// ownUpload 获取当前用户的上传会话，失败时已写入响应
+func (s *UserService) ownUpload(c *gin.Context) (*models.UploadSession, bool) {
+	userID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token"})
+		return nil, false
+	}
+	session, err := s.uploads.Get(c, userID.(uint64), c.Param("uploadId"))
+	if err != nil {
+		if errors.Is(err, ErrUploadNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "上传不存在或已过期"})
+			return nil, false
+		}
+		log.Printf("获取上传会话失败, err: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "获取上传失败"})
+		return nil, false
+	}
+	return session, true
+}
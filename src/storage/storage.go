@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Storage 媒体文件的存储后端，对象由键寻址，键由调用方生成
+type Storage interface {
+	// Put 写入对象，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open 打开对象用于读取，不存在时返回 ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// NewFromEnv 按环境变量创建存储后端
+// MEDIA_STORAGE 选择后端，目前只支持 local（默认）；MEDIA_DIR 为本地存储目录，默认 ./data/media
+func NewFromEnv() (Storage, error) {
+	switch backend := os.Getenv("MEDIA_STORAGE"); backend {
+	case "", "local":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "./data/media"
+		}
+		return NewLocalStorage(dir)
+	default:
+		return nil, fmt.Errorf("不支持的存储后端: %s", backend)
+	}
+}
+
+// validKey 键只允许字母、数字和 -_./，不能以 / 开头或包含 ..
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "..") {
+		return false
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
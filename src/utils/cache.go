@@ -16,3 +16,58 @@ func UserIDCacheKey(userID uint64) string {
 func FriendsCacheKey(userID uint64) string {
 	return fmt.Sprintf("friends:%d", userID)
 }
+
+// 生成群成员列表缓存键
+func GroupMembersCacheKey(groupID uint64) string {
+	return fmt.Sprintf("group:members:%d", groupID)
+}
+
+// 生成用户连接注册表键，记录用户连接所在的实例
+func ConnRegistryKey(userID uint64) string {
+	return fmt.Sprintf("conn:user:%d", userID)
+}
+
+// 生成实例专属的消息频道
+func InstanceChannel(instanceID string) string {
+	return fmt.Sprintf("im:instance:%s", instanceID)
+}
+
+// 生成用户某类设备的会话集合键，按登录时间排序
+func DeviceSessionsKey(userID uint64, deviceClass string) string {
+	return fmt.Sprintf("conn:devices:%d:%s", userID, deviceClass)
+}
+
+// 生成设备断线时未确认消息的暂存键
+func PendingMessagesKey(userID uint64, deviceID string) string {
+	return fmt.Sprintf("conn:pending:%d:%s", userID, deviceID)
+}
+
+// 在线用户集合键，score 为在线状态的过期时间
+func PresenceKey() string {
+	return "presence:online"
+}
+
+// 生成已吊销令牌的键
+func TokenRevokedKey(jti string) string {
+	return fmt.Sprintf("token:revoked:%s", jti)
+}
+
+// 生成用户令牌全部吊销时间的键，早于该时间签发的令牌无效
+func UserTokensRevokedKey(userID uint64) string {
+	return fmt.Sprintf("token:revoked_before:%d", userID)
+}
+
+// 分片上传会话的键
+func UploadSessionKey(uploadID string) string {
+	return fmt.Sprintf("upload:session:%s", uploadID)
+}
+
+// 分片上传已接收分片序号集合的键
+func UploadChunksKey(uploadID string) string {
+	return fmt.Sprintf("upload:chunks:%s", uploadID)
+}
+
+// 未完成的分片上传集合键，score 为过期时间，用于清理残留分片
+func PendingUploadsKey() string {
+	return "upload:pending"
+}
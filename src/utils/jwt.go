@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
@@ -11,34 +14,104 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// 令牌类型与有效期
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// 签发时间等时间声明精确到毫秒，以便与 RevokeUserTokens 记录的毫秒级吊销时间比较
+func init() {
+	jwt.TimePrecision = time.Millisecond
+}
+
 // 自定义声明结构体，包含用户ID等信息
 type Claims struct {
-	UserID uint64 `json:"user_id"`
+	UserID    uint64 `json:"user_id"`
+	TokenType string `json:"typ"`
 	jwt.RegisteredClaims
 }
 
-// 密钥（生产环境应从配置文件或环境变量获取）
-var jwtKey = []byte("my-secret-key")
-
+// GenerateToken 签发访问令牌
 func GenerateToken(userID uint64) (string, error) {
-	// 设置过期时间（例如24小时）
-	expirationTime := time.Now().Add(24 * time.Hour)
+	return signToken(userID, TokenTypeAccess, AccessTokenTTL)
+}
+
+// GenerateRefreshToken 签发刷新令牌，只能用于换取新的访问令牌
+func GenerateRefreshToken(userID uint64) (string, error) {
+	return signToken(userID, TokenTypeRefresh, RefreshTokenTTL)
+}
+
+func signToken(userID uint64, tokenType string, ttl time.Duration) (string, error) {
+	ring, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 
 	// 创建声明
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
 	claims := &Claims{
-		UserID: userID,
+		UserID:    userID,
+		TokenType: tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        hex.EncodeToString(jti),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    "im_server",
 		},
 	}
 
-	// 创建 Token 对象，使用 HS256 算法
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// 使用当前签发密钥，kid 写入头部以便轮换期间选择验证密钥
+	token := jwt.NewWithClaims(ring.active.method, claims)
+	token.Header["kid"] = ring.active.kid
 
 	// 生成签名字符串
-	return token.SignedString(jwtKey)
+	return token.SignedString(ring.active.sign)
+}
+
+// ParseToken 验证令牌签名、有效期、类型以及是否已被吊销
+func ParseToken(tokenStr, tokenType string) (*Claims, error) {
+	ring, err := currentKeyring()
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ring.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+		// 验证签名算法与密钥一致，防止算法替换
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.verify, nil
+	}, jwt.WithIssuer("im_server"), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	if claims.TokenType != tokenType {
+		return nil, fmt.Errorf("unexpected token type: %q", claims.TokenType)
+	}
+	revoked, err := isTokenRevoked(context.Background(), claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
 }
 
 func JWTAuthMiddlewareForWS() gin.HandlerFunc {
@@ -46,6 +119,7 @@ func JWTAuthMiddlewareForWS() gin.HandlerFunc {
 		cookie, err := c.Cookie("token")
 		if err != nil {
 			c.JSON(401, gin.H{"error": "未找到认证 Cookie"})
+			c.Abort()
 			return
 		}
 
@@ -79,15 +153,8 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 }
 
 func praseToken(c *gin.Context, tokenStr string) {
-	// 解析 Token
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		// 验证签名算法
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return jwtKey, nil
-	})
+	// 解析 Token，只接受访问令牌
+	claims, err := ParseToken(tokenStr, TokenTypeAccess)
 
 	// 验证 Token
 	if err != nil {
@@ -96,12 +163,11 @@ func praseToken(c *gin.Context, tokenStr string) {
 			c.Abort()
 			return
 		}
-		c.JSON(401, gin.H{"error": "无效的Token"})
-		c.Abort()
-		return
-	}
-
-	if !token.Valid {
+		if errors.Is(err, ErrTokenRevoked) {
+			c.JSON(401, gin.H{"error": "Token已失效"})
+			c.Abort()
+			return
+		}
 		c.JSON(401, gin.H{"error": "无效的Token"})
 		c.Abort()
 		return
@@ -110,5 +176,6 @@ func praseToken(c *gin.Context, tokenStr string) {
 	// 将用户ID存入上下文，后续处理可直接获取
 	log.Printf("set userID %v\n", claims.UserID)
 	c.Set("user_id", claims.UserID)
+	c.Set("claims", claims)
 	c.Next()
 }
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtKey 一把签名密钥，仅有公钥（或轮换下线）的密钥只用于验证
+type jwtKey struct {
+	kid    string
+	method jwt.SigningMethod
+	sign   any // HMAC 为 []byte，RSA/EdDSA 为私钥；为 nil 时不能签发
+	verify any // HMAC 为 []byte，RSA/EdDSA 为公钥
+}
+
+// jwtKeyring 当前签发密钥与全部可验证的密钥
+type jwtKeyring struct {
+	active *jwtKey
+	keys   map[string]*jwtKey
+}
+
+var ErrJWTKeyMissing = errors.New("未配置 JWT_KEYS 或 JWT_SECRET")
+
+// minHMACKeyLen HMAC 密钥的最小长度，JWT_SECRET 与 HMAC 密钥文件同样适用
+const minHMACKeyLen = 32
+
+var (
+	keyringOnce sync.Once
+	keyring     *jwtKeyring
+	keyringErr  error
+)
+
+// InitJWT 加载签名密钥，启动时调用以尽早暴露配置错误
+//
+// JWT_KEYS 格式为逗号分隔的 "kid:算法:密钥文件"，第一项为当前签发密钥，其余仅用于验证，
+// 例如 "k2:EdDSA:/keys/k2.pem,k1:HS256:/keys/k1.key"。
+// 支持 HS256/HS384/HS512（文件内容为密钥）、RS256/RS384/RS512、EdDSA（PEM 私钥或公钥）。
+// 未配置 JWT_KEYS 时使用 JWT_SECRET 作为 HS256 密钥（至少 32 字节），两者都未配置时返回 ErrJWTKeyMissing。
+func InitJWT() error {
+	keyringOnce.Do(func() {
+		keyring, keyringErr = loadKeyring(os.Getenv("JWT_KEYS"), os.Getenv("JWT_SECRET"))
+	})
+	return keyringErr
+}
+
+func currentKeyring() (*jwtKeyring, error) {
+	if err := InitJWT(); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+func loadKeyring(spec, secret string) (*jwtKeyring, error) {
+	ring := &jwtKeyring{keys: make(map[string]*jwtKey)}
+	if spec == "" {
+		if secret == "" {
+			return nil, ErrJWTKeyMissing
+		}
+		if len(secret) < minHMACKeyLen {
+			return nil, fmt.Errorf("JWT_SECRET 至少 %d 字节", minHMACKeyLen)
+		}
+		key := &jwtKey{kid: "default", method: jwt.SigningMethodHS256, sign: []byte(secret), verify: []byte(secret)}
+		ring.active = key
+		ring.keys[key.kid] = key
+		return ring, nil
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("JWT_KEYS 格式错误: %q", item)
+		}
+		key, err := loadJWTKey(parts[0], parts[1], parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("加载密钥 %s 失败: %w", parts[0], err)
+		}
+		if _, ok := ring.keys[key.kid]; ok {
+			return nil, fmt.Errorf("密钥ID重复: %s", key.kid)
+		}
+		ring.keys[key.kid] = key
+		if ring.active == nil {
+			ring.active = key
+		}
+	}
+	if ring.active.sign == nil {
+		return nil, fmt.Errorf("当前签发密钥 %s 缺少私钥", ring.active.kid)
+	}
+	return ring, nil
+}
+
+func loadJWTKey(kid, alg, path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		return nil, fmt.Errorf("不支持的算法: %s", alg)
+	}
+	key := &jwtKey{kid: kid, method: method}
+
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) < minHMACKeyLen {
+			return nil, fmt.Errorf("HMAC 密钥至少 %d 字节", minHMACKeyLen)
+		}
+		key.sign, key.verify = secret, secret
+	case *jwt.SigningMethodRSA:
+		if priv, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			key.sign, key.verify = priv, &priv.PublicKey
+		} else if pub, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+			key.verify = pub
+		} else {
+			return nil, errors.New("无法解析 RSA 密钥")
+		}
+	case *jwt.SigningMethodEd25519:
+		if priv, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+			key.sign, key.verify = priv, priv.(crypto.Signer).Public()
+		} else if pub, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+			key.verify = pub
+		} else {
+			return nil, errors.New("无法解析 Ed25519 密钥")
+		}
+	default:
+		return nil, fmt.Errorf("不支持的算法: %s", alg)
+	}
+	return key, nil
+}
+
+// JWKS 导出非对称密钥的公钥（JSON Web Key Set），供其他服务验证令牌
+// HMAC 密钥不会导出
+func JWKS() (map[string]any, error) {
+	ring, err := currentKeyring()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]map[string]string, 0, len(ring.keys))
+	for _, k := range ring.keys {
+		switch pub := k.verify.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": k.kid,
+				"alg": k.method.Alg(),
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "OKP",
+				"kid": k.kid,
+				"alg": k.method.Alg(),
+				"use": "sig",
+				"crv": "Ed25519",
+				"x":   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return map[string]any{"keys": keys}, nil
+}
@@ -18,11 +18,3 @@ func Md5Enconde(data string) string {
 func MD5Enconde(data string) string {
 	return strings.ToUpper(Md5Enconde(data))
 }
-
-func MakePassword(plainPwd string, salt string) string {
-	return MD5Enconde(plainPwd + salt)
-}
-
-func VaildPassword(plainPwd string, salt string, pwd string) bool {
-	return MD5Enconde(plainPwd+salt) == pwd
-}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher 密码哈希算法，哈希结果自带算法标识和参数，无需单独保存盐
+type PasswordHasher interface {
+	// Hash 生成编码后的哈希
+	Hash(plainPwd string) (string, error)
+	// Match 判断编码后的哈希是否由该算法生成
+	Match(encoded string) bool
+	// Verify 校验密码
+	Verify(plainPwd, encoded string) (bool, error)
+	// NeedsRehash 哈希参数低于当前配置时需要重新哈希
+	NeedsRehash(encoded string) bool
+}
+
+var errInvalidHash = errors.New("密码哈希格式无效")
+
+// Argon2idHasher argon2id 哈希，编码格式：$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{Memory: 64 * 1024, Time: 3, Threads: 2, SaltLen: 16, KeyLen: 32}
+}
+
+func (h *Argon2idHasher) Hash(plainPwd string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(plainPwd), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Match(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h *Argon2idHasher) Verify(plainPwd, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	actual := argon2.IDKey([]byte(plainPwd), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(actual, key) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory < h.Memory || params.Time < h.Time || params.Threads < h.Threads || uint32(len(key)) < h.KeyLen
+}
+
+func decodeArgon2id(encoded string) (params Argon2idHasher, salt, key []byte, err error) {
+	// ["", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash]
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return params, nil, nil, errInvalidHash
+	}
+	return params, salt, key, nil
+}
+
+// BcryptHasher bcrypt 哈希
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher() *BcryptHasher {
+	return &BcryptHasher{Cost: 12}
+}
+
+func (h *BcryptHasher) Hash(plainPwd string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plainPwd), h.Cost)
+	return string(hash), err
+}
+
+func (h *BcryptHasher) Match(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h *BcryptHasher) Verify(plainPwd, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(plainPwd))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.Cost
+}
+
+// 新密码使用的哈希算法，可通过环境变量 PASSWORD_HASHER 选择 argon2id（默认）或 bcrypt
+var passwordHasher = newPasswordHasher(os.Getenv("PASSWORD_HASHER"))
+
+// 校验已有哈希时支持的全部算法
+var passwordHashers = []PasswordHasher{NewArgon2idHasher(), NewBcryptHasher()}
+
+func newPasswordHasher(name string) PasswordHasher {
+	switch name {
+	case "", "argon2id":
+		return NewArgon2idHasher()
+	case "bcrypt":
+		return NewBcryptHasher()
+	default:
+		log.Printf("未知的 PASSWORD_HASHER: %s，使用 argon2id", name)
+		return NewArgon2idHasher()
+	}
+}
+
+// HashPassword 使用当前算法生成密码哈希
+func HashPassword(plainPwd string) (string, error) {
+	return passwordHasher.Hash(plainPwd)
+}
+
+// VerifyPassword 校验密码，needsRehash 表示校验通过但哈希需要升级（旧的MD5哈希或算法参数过低）
+// salt 仅用于旧的MD5哈希，新哈希不再使用
+func VerifyPassword(plainPwd, salt, encoded string) (ok, needsRehash bool) {
+	if !strings.HasPrefix(encoded, "$") {
+		// 旧格式：MD5(password+salt)
+		legacy := MD5Enconde(plainPwd + salt)
+		ok := subtle.ConstantTimeCompare([]byte(legacy), []byte(encoded)) == 1
+		return ok, ok
+	}
+	for _, h := range passwordHashers {
+		if !h.Match(encoded) {
+			continue
+		}
+		ok, err := h.Verify(plainPwd, encoded)
+		if err != nil {
+			log.Printf("校验密码失败: %v", err)
+			return false, false
+		}
+		return ok, ok && (!passwordHasher.Match(encoded) || passwordHasher.NeedsRehash(encoded))
+	}
+	return false, false
+}
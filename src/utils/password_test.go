@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	current, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	weak := &Argon2idHasher{Memory: 1024, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32}
+	weakHash, err := weak.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := (&BcryptHasher{Cost: bcrypt.MinCost}).Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		password    string
+		salt        string
+		encoded     string
+		ok          bool
+		needsRehash bool
+	}{
+		{name: "MD5旧哈希", password: "secret", salt: "123", encoded: MD5Enconde("secret123"), ok: true, needsRehash: true},
+		{name: "MD5旧哈希密码错误", password: "wrong", salt: "123", encoded: MD5Enconde("secret123")},
+		{name: "MD5旧哈希盐错误", password: "secret", salt: "456", encoded: MD5Enconde("secret123")},
+		// 旧哈希以大写十六进制保存
+		{name: "MD5旧哈希小写", password: "secret", salt: "123", encoded: Md5Enconde("secret123")},
+		{name: "空的旧哈希", password: "", salt: "", encoded: ""},
+		{name: "当前算法", password: "secret", encoded: current, ok: true},
+		{name: "当前算法密码错误", password: "wrong", encoded: current},
+		{name: "参数过低", password: "secret", encoded: weakHash, ok: true, needsRehash: true},
+		{name: "bcrypt", password: "secret", encoded: bcryptHash, ok: true, needsRehash: true},
+		{name: "bcrypt密码错误", password: "wrong", encoded: bcryptHash},
+		{name: "格式无效", password: "secret", encoded: "$argon2id$v=19$broken"},
+		{name: "未知算法", password: "secret", encoded: "$unknown$abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash := VerifyPassword(tt.password, tt.salt, tt.encoded)
+			if ok != tt.ok || needsRehash != tt.needsRehash {
+				t.Errorf("VerifyPassword = (%v, %v), want (%v, %v)", ok, needsRehash, tt.ok, tt.needsRehash)
+			}
+		})
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	argon := NewArgon2idHasher()
+	bc := &BcryptHasher{Cost: bcrypt.MinCost + 1}
+	lowCost, err := (&BcryptHasher{Cost: bcrypt.MinCost}).Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		hasher  PasswordHasher
+		encoded string
+		want    bool
+	}{
+		{name: "argon2id参数相同", hasher: argon, encoded: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdHNhbHRzYWx0c2FsdA$" + "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"},
+		{name: "argon2id内存过低", hasher: argon, encoded: "$argon2id$v=19$m=1024,t=3,p=2$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U", want: true},
+		{name: "argon2id版本不同", hasher: argon, encoded: "$argon2id$v=16$m=65536,t=3,p=2$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U", want: true},
+		{name: "bcrypt成本过低", hasher: bc, encoded: lowCost, want: true},
+		{name: "bcrypt格式无效", hasher: bc, encoded: "$2a$xx", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(tt.encoded); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -34,13 +34,6 @@ func CreateRedisConn(addr string) *redis.Client {
 	return rdb
 }
 
-func Publish(redis *redis.Client, ctx context.Context, channel string, msg string) {
-	redis.Publish(ctx, channel, msg)
-}
-
-func Subscription(redis *redis.Client, ctx context.Context, channel string) (string, error) {
-	pubsub := redis.Subscribe(ctx, channel)
-	msg, err := pubsub.ReceiveMessage(ctx)
-	log.Println(msg.String())
-	return msg.Payload, err
+func Publish(redis *redis.Client, ctx context.Context, channel string, msg string) error {
+	return redis.Publish(ctx, channel, msg).Err()
 }
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrSignatureInvalid   = errors.New("invalid signature")
+	ErrSignatureExpired   = errors.New("signature expired")
+	ErrMediaURLKeyMissing = errors.New("未配置 MEDIA_URL_SECRET")
+)
+
+var mediaURLSecret []byte
+
+// InitMediaURLSigner 加载媒体下载链接的签名密钥，多个实例须配置相同的 MEDIA_URL_SECRET
+// 未配置时返回 ErrMediaURLKeyMissing，此时不能签发也不能验证链接
+func InitMediaURLSigner() error {
+	secret := os.Getenv("MEDIA_URL_SECRET")
+	if secret == "" {
+		return ErrMediaURLKeyMissing
+	}
+	mediaURLSecret = []byte(secret)
+	return nil
+}
+
+// mediaSignature 对附件ID、持有者、规格和过期时间签名，任一参数被改动签名即失效
+func mediaSignature(id, userID uint64, variant string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, mediaURLSecret)
+	mac.Write([]byte(strconv.FormatUint(id, 10) + ":" + strconv.FormatUint(userID, 10) + ":" + variant + ":" + strconv.FormatInt(expiresAt, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignMedia 为用户签发附件的下载签名，variant 为空表示原文件，否则为缩略图规格
+func SignMedia(id, userID uint64, variant string, expiresAt time.Time) string {
+	return mediaSignature(id, userID, variant, expiresAt.Unix())
+}
+
+// VerifyMedia 校验下载签名及有效期
+func VerifyMedia(id, userID uint64, variant string, expiresAt int64, sig string) error {
+	if len(mediaURLSecret) == 0 {
+		return ErrSignatureInvalid
+	}
+	if !hmac.Equal([]byte(sig), []byte(mediaSignature(id, userID, variant, expiresAt))) {
+		return ErrSignatureInvalid
+	}
+	if time.Now().Unix() > expiresAt {
+		return ErrSignatureExpired
+	}
+	return nil
+}
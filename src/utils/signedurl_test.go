@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInitMediaURLSigner(t *testing.T) {
+	t.Setenv("MEDIA_URL_SECRET", "")
+	if err := InitMediaURLSigner(); !errors.Is(err, ErrMediaURLKeyMissing) {
+		t.Fatalf("err = %v, want ErrMediaURLKeyMissing", err)
+	}
+}
+
+func TestVerifyMedia(t *testing.T) {
+	t.Setenv("MEDIA_URL_SECRET", "test-media-secret")
+	if err := InitMediaURLSigner(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { mediaURLSecret = nil })
+
+	valid := time.Now().Add(time.Minute)
+	expired := time.Now().Add(-time.Second)
+	sig := SignMedia(1, 2, "", valid)
+	thumbSig := SignMedia(1, 2, "thumb", valid)
+	tampered := []byte(sig)
+	tampered[0] ^= 1
+
+	tests := []struct {
+		name      string
+		id        uint64
+		userID    uint64
+		variant   string
+		expiresAt int64
+		sig       string
+		want      error
+	}{
+		{name: "有效", id: 1, userID: 2, expiresAt: valid.Unix(), sig: sig},
+		{name: "缩略图", id: 1, userID: 2, variant: "thumb", expiresAt: valid.Unix(), sig: thumbSig},
+		{name: "已过期", id: 1, userID: 2, expiresAt: expired.Unix(), sig: SignMedia(1, 2, "", expired), want: ErrSignatureExpired},
+		{name: "改动附件ID", id: 3, userID: 2, expiresAt: valid.Unix(), sig: sig, want: ErrSignatureInvalid},
+		{name: "改动持有者", id: 1, userID: 4, expiresAt: valid.Unix(), sig: sig, want: ErrSignatureInvalid},
+		{name: "原文件签名用于缩略图", id: 1, userID: 2, variant: "thumb", expiresAt: valid.Unix(), sig: sig, want: ErrSignatureInvalid},
+		{name: "延长有效期", id: 1, userID: 2, expiresAt: valid.Add(time.Hour).Unix(), sig: sig, want: ErrSignatureInvalid},
+		{name: "篡改签名", id: 1, userID: 2, expiresAt: valid.Unix(), sig: string(tampered), want: ErrSignatureInvalid},
+		{name: "空签名", id: 1, userID: 2, expiresAt: valid.Unix(), want: ErrSignatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyMedia(tt.id, tt.userID, tt.variant, tt.expiresAt, tt.sig)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyMediaWithoutSecret(t *testing.T) {
+	mediaURLSecret = nil
+	// 未配置密钥时空密钥算出的签名也不能通过
+	sig := SignMedia(1, 2, "", time.Now().Add(time.Minute))
+	if err := VerifyMedia(1, 2, "", time.Now().Add(time.Minute).Unix(), sig); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("err = %v, want ErrSignatureInvalid", err)
+	}
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 订阅重连与缓冲参数
+const (
+	subscribeMinBackoff  = 100 * time.Millisecond
+	subscribeMaxBackoff  = 10 * time.Second
+	subscriberBufferSize = 1024
+)
+
+// Subscriber 长连接订阅者：持续消费 pubsub.Channel()，订阅失败或连接关闭时按指数退避重连
+// 统计数据通过 expvar 暴露在内部调试地址（DEBUG_ADDR）的 /debug/vars 下
+type Subscriber struct {
+	redis    *redis.Client
+	channels []string
+	stats    *expvar.Map
+}
+
+// NewSubscriber 创建订阅者，name 用作 expvar 中的统计项名称，进程内需唯一
+func NewSubscriber(redis *redis.Client, name string, channels ...string) *Subscriber {
+	return &Subscriber{redis: redis, channels: channels, stats: expvar.NewMap(name)}
+}
+
+// Run 阻塞消费订阅消息直到 ctx 取消
+func (s *Subscriber) Run(ctx context.Context, handle func(payload string)) {
+	backoff := subscribeMinBackoff
+	for ctx.Err() == nil {
+		pubsub := s.redis.Subscribe(ctx, s.channels...)
+		// 等待订阅确认，确保连接可用
+		if _, err := pubsub.Receive(ctx); err != nil {
+			pubsub.Close()
+			s.stats.Add("subscribe_errors", 1)
+			log.Printf("订阅 %v 失败: %v, %v 后重试", s.channels, err, backoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff = min(backoff*2, subscribeMaxBackoff)
+			continue
+		}
+		backoff = subscribeMinBackoff
+		s.stats.Add("subscribes", 1)
+		log.Printf("订阅 %v 成功", s.channels)
+
+		// ctx 取消时关闭订阅以结束下面的 range
+		stop := context.AfterFunc(ctx, func() { pubsub.Close() })
+		// 连接断开时 go-redis 会在内部重连并恢复订阅，期间发布的消息会丢失
+		for msg := range pubsub.Channel(redis.WithChannelSize(subscriberBufferSize)) {
+			s.stats.Add("received", 1)
+			handle(msg.Payload)
+		}
+		stop()
+		pubsub.Close()
+		log.Printf("订阅 %v 已关闭", s.channels)
+	}
+}
+
+// MarkDropped 记录一条未能处理而丢弃的消息
+func (s *Subscriber) MarkDropped() {
+	s.stats.Add("dropped", 1)
+}
+
+// MarkLate 记录一条从发布到接收延迟过高的消息
+func (s *Subscriber) MarkLate() {
+	s.stats.Add("late", 1)
+}